@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// adminResource describes a resource in the app for the admin resources template
+type adminResource struct {
+	Name    string // the resource package e.g. pages
+	Title   string // e.g. Pages
+	URL     string // the url of the resource index, with any parent ids as {parent_id}
+	Search  string // the quoted string columns which are searched e.g. "name", "summary"
+	UUID    bool   // true if the resource has uuid ids
+	Audited bool   // true if the resource records the users who update it
+}
+
+// The routes added for the admin by generate admin
+const adminRoutesTemplate = `
+    r.Add("/admin", adminactions.HandleIndex)
+    r.Add("/admin/{resources:[a-z_]+}", adminactions.HandleResourceIndex)
+    r.Add("/admin/{resources:[a-z_]+}/destroy", adminactions.HandleResourceDestroy).Post()`
+
+// The template of the list of resources shown in the admin, which is regenerated whenever resources are generated
+// It renders nothing when the other admin templates are copied, as it is rendered with the resources by generateAdminResources
+const adminResourcesTemplate = "actions/resources.go.tmpl"
+
+// generateAdmin generates an admin at /admin, with a table of records for each resource in the app
+func generateAdmin() {
+	resourceName = "admin"
+	resourceParent = ""
+	resourceTable = ""
+	columns = make(map[string]string, 0)
+	columnModifiers = make(map[string][]string, 0)
+
+	if !hasAuth() {
+		fmt.Println("Warning: the admin is open to everyone, run fragmenta generate auth then generate admin again to restrict it to admins")
+	}
+
+	addRoutes(reifyString(adminRoutesTemplate), adminActionsPath())
+
+	dstPath := path.Join(fullAppPath(), appGeneratePath(), "admin")
+	fmt.Printf("Creating files at %s\n", dstPath)
+	copyAndReifyFiles(templateSet("fragmenta_admin"), dstPath)
+
+	generateAdminResources()
+}
+
+// syncAdmin regenerates the list of resources in the admin after resources are generated or destroyed,
+// if the app has an admin
+func syncAdmin() {
+	if !fileExists(path.Join(fullAppPath(), appGeneratePath(), "admin")) {
+		return
+	}
+	generateAdminResources()
+}
+
+// generateAdminResources writes the list of resources found in the app to the admin actions
+func generateAdminResources() {
+	resources := adminResources()
+
+	var names []string
+	for _, r := range resources {
+		names = append(names, r.Name)
+	}
+	fmt.Printf("Generating admin for resources %s\n", strings.Join(names, ", "))
+
+	tmpl, err := fs.ReadFile(templateSet("fragmenta_admin"), adminResourcesTemplate)
+	if err != nil {
+		fmt.Println("Error reading admin resources template: ", err)
+		return
+	}
+
+	context := map[string]interface{}{
+		"fragmenta_admin_resources": true,
+		"AppPath":                   path.Join(appPath(), appGeneratePath()),
+		"Resources":                 resources,
+	}
+	output := []byte(renderTemplate(string(tmpl), context))
+
+	formatted, err := format.Source(output)
+	if err == nil {
+		output = formatted
+	}
+
+	dst := path.Join(fullAppPath(), appGeneratePath(), "admin", "actions", "resources.go")
+	written, err := overwriteGeneratedFile(dst, output)
+	if err != nil {
+		fmt.Println("Error writing admin resources: ", dst)
+		return
+	}
+
+	if written {
+		fmt.Printf("=> %s\n", dst)
+	}
+}
+
+// adminResources returns the resources in the app, which are the packages under appGeneratePath with a model
+func adminResources() []adminResource {
+	var resources []adminResource
+
+	srcPath := path.Join(fullAppPath(), appGeneratePath())
+	entries, err := ioutil.ReadDir(srcPath)
+	if err != nil {
+		fmt.Printf("Error reading resources at %s %s\n", srcPath, err)
+		return resources
+	}
+
+	indexURLs := adminIndexURLs()
+
+	for _, e := range entries {
+		if !e.IsDir() || e.Name() == "admin" {
+			continue
+		}
+
+		resourcePath := path.Join(srcPath, e.Name())
+		_, fields := modelFields(resourcePath)
+		if fields == nil {
+			continue
+		}
+
+		// Search the string columns which are params, so that columns like password hashes are not searched
+		// uuid and json columns also have string fields, so the admin casts the columns to text to search them
+		params := modelAllowedParams(resourcePath)
+		var search []string
+		for _, k := range sortedKeys(fields) {
+			if fields[k] == "string" && contains(k, params) {
+				search = append(search, fmt.Sprintf("%q", k))
+			}
+		}
+
+		_, audited := fields["updated_by"]
+		resources = append(resources, adminResource{
+			Name:    e.Name(),
+			Title:   ToCamel(e.Name()),
+			URL:     indexURLs[e.Name()],
+			Search:  strings.Join(search, ", "),
+			UUID:    fields["id"] == "string",
+			Audited: audited,
+		})
+	}
+
+	return resources
+}
+
+// adminIndexURLs reads the routes file and returns the url of the index of each resource, keyed by resource package
+func adminIndexURLs() map[string]string {
+	urls := make(map[string]string, 0)
+
+	data, err := ioutil.ReadFile(appRoutesFilePath())
+	if err != nil {
+		return urls
+	}
+
+	routes, err := parseRoutes(data)
+	if err != nil {
+		return urls
+	}
+
+	prefix := path.Join(appPath(), appGeneratePath()) + "/"
+	for _, r := range routes {
+		if r.Handler != "HandleIndex" || path.Base(r.Package) != "actions" || !strings.HasPrefix(r.Package, prefix) {
+			continue
+		}
+		name := strings.TrimPrefix(path.Dir(r.Package), prefix)
+		urls[name] = routeParamRegexp.ReplaceAllString(r.Pattern, "{$1}")
+	}
+
+	return urls
+}
+
+// modelAllowedParams parses the go files in the resource package at resourcePath,
+// and returns the params listed by its AllowedParams func
+func modelAllowedParams(resourcePath string) []string {
+	var params []string
+
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, resourcePath, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return params
+	}
+
+	for _, pkg := range pkgs {
+		ast.Inspect(pkg, func(n ast.Node) bool {
+			f, ok := n.(*ast.FuncDecl)
+			if !ok || f.Name.Name != "AllowedParams" || f.Recv != nil || f.Body == nil {
+				return true
+			}
+			ast.Inspect(f.Body, func(n ast.Node) bool {
+				lit, ok := n.(*ast.BasicLit)
+				if ok && lit.Kind == token.STRING {
+					param, _ := strconv.Unquote(lit.Value)
+					params = append(params, param)
+				}
+				return true
+			})
+			return false
+		})
+	}
+
+	return params
+}
+
+// adminActionsPath returns the import path of the admin actions
+func adminActionsPath() string {
+	return path.Join(appPath(), appGeneratePath(), "admin", "actions")
+}
+
+// hasAuth returns true if the app has a users resource with auth, generated by generate auth
+func hasAuth() bool {
+	return fileExists(path.Join(fullAppPath(), appGeneratePath(), "users", "auth.go"))
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"path"
+)
+
+// Generate a JSON api for a resource, with a model if the resource does not yet have one
+func generateAPI(args []string) {
+
+	joinSQL, err := parseResourceArgs(args)
+	if err != nil {
+		fmt.Printf("Error generating api %s\n", err)
+		return
+	}
+
+	// For a destination, use the set path or default to ./src/xxx
+	dstPath := path.Join(fullAppPath(), appGeneratePath(), ToPlural(resourceName))
+
+	// If the resource has already been generated, we only need to add the api handlers for the fields of its model,
+	// otherwise the model is rendered from the resource templates with the fields in args
+	if fileExists(path.Join(dstPath, ToPlural(resourceName)+".go")) {
+		fmt.Printf("Using existing model for %s\n", resourceName)
+		if len(args) > 1 {
+			fmt.Printf("Ignoring the fields in args, the api uses the fields of the model\n")
+		}
+		err = modelColumns(dstPath)
+		if err != nil {
+			fmt.Printf("Error generating api %s\n", err)
+			return
+		}
+	} else {
+		generateResourceMigration(joinSQL)
+		fmt.Printf("Creating model at %s\n", dstPath)
+		copyAndReifyFile(templateSet("fragmenta_resources"), "fragmenta_resources.go.tmpl", dstPath)
+	}
+
+	// The api uses the policy in the resource actions, so generate it if the resource has no actions
+	if !fileExists(path.Join(dstPath, "actions", "policy.go")) {
+		copyAndReifyFile(templateSet("fragmenta_resources"), "actions/policy.go.tmpl", dstPath)
+	}
+
+	generateAPIRoutes()
+
+	fmt.Printf("Creating files at %s\n", path.Join(dstPath, "api"))
+	copyAndReifyFiles(templateSet("fragmenta_api"), dstPath)
+
+	generateOpenAPI()
+	syncAdmin()
+}
+
+// modelColumns sets the columns from the fields of the existing model at resourcePath, so that the api matches it
+// Fields which are not in the AllowedParams of the model are hidden, so are not sent in requests or responses
+func modelColumns(resourcePath string) error {
+	name, fields := modelFields(resourcePath)
+	if name == "" {
+		return fmt.Errorf("no model found at %s", resourcePath)
+	}
+	allowed := modelAllowedParams(resourcePath)
+
+	columns = make(map[string]string, 0)
+	columnModifiers = make(map[string][]string, 0)
+	hiddenColumns = nil
+	for _, col := range sortedKeys(fields) {
+		// The id of uuid keyed models is a field of the model, but is set by the database
+		if col == "id" {
+			continue
+		}
+
+		fieldType, ok := fromGoType(fields[col])
+		if !ok {
+			fmt.Printf("Skipping field %s of %s, unknown type %s\n", col, name, fields[col])
+			continue
+		}
+		columns[col] = fieldType
+
+		if !contains(col, allowed) {
+			hiddenColumns = append(hiddenColumns, col)
+		}
+	}
+	return nil
+}
+
+// Generate the api routes using standard REST verbs and insert them into the routes.go file
+func generateAPIRoutes() {
+
+	routesTemplate := `
+    r.Add("/api/[[.fragmenta_resources]]", [[.fragmenta_resource]]api.HandleIndex)
+    r.Add("/api/[[.fragmenta_resources]]", [[.fragmenta_resource]]api.HandleCreate).Post()
+    r.Add("/api/[[.fragmenta_resources]]/{id:[[.fragmenta_id_pattern]]}", [[.fragmenta_resource]]api.HandleShow)
+    r.Add("/api/[[.fragmenta_resources]]/{id:[[.fragmenta_id_pattern]]}", [[.fragmenta_resource]]api.HandleUpdate).Put()
+    r.Add("/api/[[.fragmenta_resources]]/{id:[[.fragmenta_id_pattern]]}", [[.fragmenta_resource]]api.HandleDestroy).Delete()`
+
+	resourceRoutes := reifyString(routesTemplate)
+	resourceImport := reifyString("[[.fragmenta_app_path]]/[[.fragmenta_resources]]/api")
+
+	addRoutes(resourceRoutes, resourceImport)
+}
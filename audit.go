@@ -0,0 +1,52 @@
+package main
+
+// The table which records the changes to audited resources, shared by all resources generated with --audit
+const auditTable = "audit_logs"
+
+// auditMigrationSQL returns sql to record every change to the resource table in the audit log,
+// or an empty string if the resource is not audited
+// The audit log table and trigger function are created by the migration of the first audited resource,
+// and the user recorded for each change is the updated_by of the row, which is set by the actions
+// A delete has no new row, so records the updated_by of the deleted row - code which destroys records
+// must set updated_by to the current user with Update before calling Destroy, as the actions and admin do,
+// or the delete is attributed to the last user to update the record
+// The ids are stored as text, so that resources and users may have either serial or uuid keys
+func auditMigrationSQL() string {
+	if !generateFlag("audit") {
+		return ""
+	}
+
+	tmpl := `CREATE TABLE IF NOT EXISTS [[.audit_table]] (
+id SERIAL NOT NULL,
+created_at timestamp,
+table_name text,
+record_id text,
+user_id text,
+action text,
+old_values jsonb,
+new_values jsonb
+);
+ALTER TABLE [[.audit_table]] OWNER TO [[.db_user]];
+CREATE INDEX IF NOT EXISTS [[.audit_table]]_record ON [[.audit_table]] (table_name, record_id);
+CREATE OR REPLACE FUNCTION [[.audit_table]]_record() RETURNS trigger AS $$
+BEGIN
+  IF TG_OP = 'DELETE' THEN
+    INSERT INTO [[.audit_table]] (created_at, table_name, record_id, user_id, action, old_values)
+    VALUES (now(), TG_TABLE_NAME, OLD.id, OLD.updated_by, TG_OP, to_jsonb(OLD));
+    RETURN OLD;
+  END IF;
+  INSERT INTO [[.audit_table]] (created_at, table_name, record_id, user_id, action, old_values, new_values)
+  VALUES (now(), TG_TABLE_NAME, NEW.id, NEW.updated_by, TG_OP, CASE WHEN TG_OP = 'UPDATE' THEN to_jsonb(OLD) END, to_jsonb(NEW));
+  RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER [[.table]]_audit AFTER INSERT OR UPDATE OR DELETE ON [[.table]]
+FOR EACH ROW EXECUTE PROCEDURE [[.audit_table]]_record();
+`
+	context := map[string]string{
+		"audit_table": auditTable,
+		"table":       resourceTableName(),
+		"db_user":     ConfigDevelopment["db_user"],
+	}
+	return renderTemplate(tmpl, context)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"path"
+)
+
+// The routes added for login, logout and password resets by generate auth, as well as the resource routes
+const authRoutesTemplate = `
+    r.Add("/[[.fragmenta_resources]]/login", [[.fragmenta_resource]]actions.HandleLoginShow)
+    r.Add("/[[.fragmenta_resources]]/login", [[.fragmenta_resource]]actions.HandleLogin).Post()
+    r.Add("/[[.fragmenta_resources]]/logout", [[.fragmenta_resource]]actions.HandleLogout).Post()
+    r.Add("/[[.fragmenta_resources]]/password/reset", [[.fragmenta_resource]]actions.HandlePasswordResetShow)
+    r.Add("/[[.fragmenta_resources]]/password/reset", [[.fragmenta_resource]]actions.HandlePasswordResetSend).Post()
+    r.Add("/[[.fragmenta_resources]]/password/reset/{token:[0-9a-f]+}", [[.fragmenta_resource]]actions.HandlePasswordResetEditShow)
+    r.Add("/[[.fragmenta_resources]]/password/reset/{token:[0-9a-f]+}", [[.fragmenta_resource]]actions.HandlePasswordReset).Post()`
+
+// authResource is set while generating the users resource for generate auth, so that the model normalises emails
+var authResource bool
+
+// generateAuth generates a users resource with hashed passwords, login and logout handlers using sessions,
+// and password reset tokens which expire, along with their routes and migration
+// Expects:
+// - generate auth
+func generateAuth() {
+	resourceName = "user"
+	resourceParent = ""
+	resourceTable = ""
+	columns = map[string]string{
+		"email":                  "text",
+		"name":                   "text",
+		"role":                   "enum(reader,editor,admin)",
+		"password_hash":          "text",
+		"reset_token_hash":       "text",
+		"reset_token_expires_at": "time",
+	}
+	columnModifiers = make(map[string][]string, 0)
+
+	// These columns are set by the auth code, not by forms or params
+	hiddenColumns = []string{"password_hash", "reset_token_hash", "reset_token_expires_at"}
+	defer func() { hiddenColumns = nil }()
+
+	authResource = true
+	defer func() { authResource = false }()
+
+	// Only admins may list and edit users, unless another role is given with --auth role
+	if !generateFlag("auth") {
+		generateFlags["auth"] = "admin"
+	}
+
+	// Sessions are signed and encrypted with the keys written to the config by fragmenta new
+	for _, key := range []string{"hmac_key", "secret_key"} {
+		if ConfigDevelopment[key] == "" {
+			fmt.Printf("Warning: no %s in config, add a random hex encoded 32 byte key to each config in fragmenta.json\n", key)
+		}
+	}
+
+	fmt.Printf("Generating auth with\n - name:%s\n - attributes:%v\n", resourceName, columns)
+
+	generateResourceMigration(reifyString("CREATE UNIQUE INDEX ON [[.fragmenta_resources]] (email);\n"))
+	generateResourceRoutes(authRoutesTemplate)
+	generateResourceFiles()
+
+	dstPath := path.Join(fullAppPath(), appGeneratePath(), ToPlural(resourceName))
+	copyAndReifyFiles(templateSet("fragmenta_auth"), dstPath)
+
+	generateResourceTests()
+	generateOpenAPI()
+	syncAdmin()
+
+	fmt.Printf("To use auth, call %s.SetupAuth(hmac_key, secret_key) with the keys from your config when the app starts\n", ToPlural(resourceName))
+	fmt.Printf("Password reset links are not sent until you set %s.SendPasswordReset to send them with your mailer\n", ToPlural(resourceName))
+	fmt.Printf("Sign up the first %s at /%s/create, which is open until a %s exists and gives them role %s\n", resourceName, ToPlural(resourceName), resourceName, authRole())
+	fmt.Printf("The %s actions are restricted to users with role %s, see the policy in %s/actions/policy.go\n", ToPlural(resourceName), authRole(), ToPlural(resourceName))
+}
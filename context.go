@@ -0,0 +1,123 @@
+package main
+
+import (
+	"path"
+	"strings"
+	"text/template"
+)
+
+// templateFuncs are the helper functions available to generator templates, e.g. [[ ToPlural .Resource.Name ]]
+var templateFuncs = template.FuncMap{
+	"ToPlural":             ToPlural,
+	"ToSingular":           ToSingular,
+	"ToCamel":              ToCamel,
+	"ToSnake":              ToSnake,
+	"Truncate":             Truncate,
+	"TruncateWithEllipsis": TruncateWithEllipsis,
+	"ToLower":              strings.ToLower,
+	"ToUpper":              strings.ToUpper,
+	"Join":                 strings.Join,
+}
+
+// templateResource describes the resource being generated for templates
+type templateResource struct {
+	Name        string // page
+	Plural      string // pages
+	Camel       string // Page
+	CamelPlural string // Pages
+	Table       string // pages
+	IDType      string // the go type of ids, int64 or string for resources with uuid keys
+}
+
+// templateField describes one column of the resource being generated for templates
+type templateField struct {
+	Column       string   // column name e.g. published_at
+	Name         string   // struct field name e.g. PublishedAt
+	Type         string   // type given to generate e.g. time
+	GoType       string   // e.g. time.Time
+	SQLType      string   // e.g. timestamp
+	ValidateType string   // the validate func used to read the column e.g. Time
+	InputType    string   // the form input type e.g. date
+	Modifiers    []string // modifiers given after the type e.g. searchable
+	Values       []string // the values of an enum e.g. draft, published
+	Hidden       bool     // true if the column is set by code, so is not shown in forms or views
+}
+
+// Has returns true if the field has the named modifier
+func (f templateField) Has(modifier string) bool {
+	return contains(modifier, f.Modifiers)
+}
+
+// templateDB describes the development database for templates
+type templateDB struct {
+	Adapter string
+	Name    string
+	User    string
+}
+
+// templateContext returns the structured values available to generator templates
+//
+//	Resource - the resource names, see templateResource
+//	Parent   - the parent resource names for nested resources, or nil
+//	Fields   - the resource columns sorted by name, see templateField
+//	AppPath  - the import path of the app source e.g. github.com/x/app/src
+//	AppName  - the app server name
+//	DB       - the development database, see templateDB
+//	Args     - the arguments given to a named generator, see generateNamed
+func templateContext() map[string]interface{} {
+	return map[string]interface{}{
+		"Resource": templateResource{
+			Name:        resourceName,
+			Plural:      ToPlural(resourceName),
+			Camel:       ToCamel(resourceName),
+			CamelPlural: ToCamel(ToPlural(resourceName)),
+			Table:       resourceTableName(),
+			IDType:      idGoType(resourceName),
+		},
+		"Parent":  parentResource(),
+		"Fields":  templateFields(),
+		"AppPath": path.Join(appPath(), appGeneratePath()),
+		"AppName": appServerName(),
+		"DB": templateDB{
+			Adapter: ConfigDevelopment["db_adapter"],
+			Name:    ConfigDevelopment["db"],
+			User:    ConfigDevelopment["db_user"],
+		},
+		"Args": generatorArgs,
+	}
+}
+
+// parentResource returns the names of the parent of a nested resource for templates, or nil if there is no parent
+func parentResource() *templateResource {
+	if resourceParent == "" {
+		return nil
+	}
+	return &templateResource{
+		Name:        resourceParent,
+		Plural:      ToPlural(resourceParent),
+		Camel:       ToCamel(resourceParent),
+		CamelPlural: ToCamel(ToPlural(resourceParent)),
+		Table:       ToPlural(resourceParent),
+		IDType:      idGoType(resourceParent),
+	}
+}
+
+// templateFields returns a description of each column for templates, sorted by column name
+func templateFields() []templateField {
+	var fields []templateField
+	for _, k := range sortedKeys(columns) {
+		fields = append(fields, templateField{
+			Column:       k,
+			Name:         ToCamel(k),
+			Type:         columns[k],
+			GoType:       toGoType(columns[k]),
+			SQLType:      toSQLType(columns[k]),
+			ValidateType: toValidateType(columns[k]),
+			InputType:    toInputType(columns[k]),
+			Modifiers:    columnModifiers[k],
+			Values:       fieldEnumValues(columns[k]),
+			Hidden:       contains(k, hiddenColumns),
+		})
+	}
+	return fields
+}
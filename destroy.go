@@ -0,0 +1,275 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// RunDestroy undoes the work of generate
+// Expects:
+// - destroy resource pages
+// Any command may be followed by --dry-run or --diff to list changes without making them
+func RunDestroy(args []string) {
+	// Remove fragmenta destroy from args list
+	args = parseGenerateFlags(args[2:])
+
+	if len(args) < 2 {
+		fmt.Println("Not enough arguments")
+		return
+	}
+	command := args[0]
+	args = args[1:]
+	switch command {
+	case "resource":
+		destroyResource(args[0])
+	default:
+		fmt.Println("Sorry, I didn't recognise that argument, you can use fragmenta destroy [resource]")
+	}
+}
+
+// destroyResource removes the files, routes and migration created by generate resource
+func destroyResource(name string) {
+	resourceName = ToSingular(name)
+	columns = make(map[string]string, 0)
+
+	fmt.Printf("Destroying resource %s\n", resourceName)
+
+	destroyResourceFiles()
+	destroyResourceRoutes()
+	destroyResourceMigration()
+	syncAdmin()
+}
+
+// destroyResourceFiles removes the resource package from the app
+func destroyResourceFiles() {
+	dstPath := path.Join(fullAppPath(), appGeneratePath(), ToPlural(resourceName))
+
+	if !fileExists(dstPath) {
+		fmt.Printf("No resource files at %s\n", dstPath)
+		return
+	}
+
+	if previewing() {
+		fmt.Printf("would remove %s\n", dstPath)
+		return
+	}
+
+	err := os.RemoveAll(dstPath)
+	if err != nil {
+		fmt.Printf("Error removing resource files at %s :%s\n", dstPath, err)
+		return
+	}
+
+	fmt.Printf("Removed resource files at %s\n", dstPath)
+}
+
+// destroyResourceRoutes removes the routes and imports inserted by generateResourceRoutes and generateAPIRoutes
+func destroyResourceRoutes() {
+	routesPath := appRoutesFilePath()
+	data, err := ioutil.ReadFile(routesPath)
+	if err != nil {
+		fmt.Printf("#error Error reading routes at:%s :%s", routesPath, err)
+		return
+	}
+
+	// Generated handler packages are imported without a name, so are used with their package names e.g. pageactions
+	packages := map[string]string{
+		reifyString("[[.fragmenta_app_path]]/[[.fragmenta_resources]]/actions"): resourceName + "actions",
+		reifyString("[[.fragmenta_app_path]]/[[.fragmenta_resources]]/api"):     resourceName + "api",
+	}
+
+	output, removed, err := removeRoutes(data, packages)
+	if err != nil {
+		fmt.Printf("#error Error removing routes at:%s :%s\n", routesPath, err)
+		return
+	}
+
+	if removed == 0 {
+		fmt.Println("No routes found for resource: ", resourceName)
+		return
+	}
+
+	written, err := overwriteGeneratedFile(routesPath, output)
+	if err != nil {
+		fmt.Println("Error writing routes file: ", routesPath)
+		return
+	}
+
+	if written {
+		fmt.Printf("Removed %d routes from %s\n", removed, routesPath)
+	}
+}
+
+// removeRoutes parses the go source of a routes file, and removes the imports of the import paths in packages,
+// and every statement which uses one of their package names, returning the formatted source and the number of routes removed
+func removeRoutes(src []byte, packages map[string]string) ([]byte, int, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "routes.go", src, parser.ParseComments)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Find the names the packages are used with in this file
+	names := map[string]bool{}
+	var ranges [][2]int
+	for _, i := range file.Imports {
+		p, _ := strconv.Unquote(i.Path.Value)
+		name, ok := packages[p]
+		if !ok {
+			continue
+		}
+		if i.Name != nil {
+			name = i.Name.Name
+		}
+		names[name] = true
+		ranges = append(ranges, lineRange(fset, src, i.Pos(), i.End()))
+	}
+
+	// Find the statements which use the packages, e.g. r.Add("/pages", pageactions.HandleIndex)
+	routes := 0
+	ast.Inspect(file, func(n ast.Node) bool {
+		block, ok := n.(*ast.BlockStmt)
+		if !ok {
+			return true
+		}
+		for _, stmt := range block.List {
+			if _, ok := stmt.(*ast.ExprStmt); ok && usesPackage(stmt, names) {
+				ranges = append(ranges, lineRange(fset, src, stmt.Pos(), stmt.End()))
+				routes++
+			}
+		}
+		return true
+	})
+
+	if routes == 0 {
+		return src, 0, nil
+	}
+
+	// Remove the ranges from the end of the file, so that earlier offsets are unchanged
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i][0] > ranges[j][0] })
+	output := append([]byte{}, src...)
+	for _, r := range ranges {
+		output = append(output[:r[0]], output[r[1]:]...)
+	}
+
+	output, err = format.Source(output)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error formatting routes %s", err)
+	}
+
+	return output, routes, nil
+}
+
+// usesPackage returns true if node refers to any of the package names
+func usesPackage(node ast.Node, names map[string]bool) bool {
+	found := false
+	ast.Inspect(node, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if ok {
+			if id, ok := sel.X.(*ast.Ident); ok && names[id.Name] {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// lineRange returns the offsets of the whole lines in src from pos to end, including the final newline
+func lineRange(fset *token.FileSet, src []byte, pos token.Pos, end token.Pos) [2]int {
+	start := fset.Position(pos).Offset
+	for start > 0 && src[start-1] != '\n' {
+		start--
+	}
+	stop := fset.Position(end).Offset
+	for stop < len(src) && src[stop] != '\n' {
+		stop++
+	}
+	if stop < len(src) {
+		stop++
+	}
+	return [2]int{start, stop}
+}
+
+// destroyResourceMigration deletes the create migration for this resource if it has not been run,
+// or generates a new migration dropping the tables it created if it has
+func destroyResourceMigration() {
+	name := fmt.Sprintf("Create-%s", ToCamel(resourceName))
+	files, err := filepath.Glob(fmt.Sprintf("./db/migrate/*-%s.sql", name))
+	if err != nil || len(files) == 0 {
+		fmt.Printf("No migration found for %s\n", name)
+		return
+	}
+
+	// Find out which migrations have already been applied to the development db,
+	// migrations are only removed if they are known not to have been applied
+	err = openDatabase(ConfigDevelopment)
+	if err != nil {
+		fmt.Printf("Error opening database, migrations for %s were not removed :%s\n", name, err)
+		return
+	}
+	migrations, err := appliedMigrations()
+	if err != nil {
+		fmt.Printf("Error reading applied migrations, migrations for %s were not removed :%s\n", name, err)
+		return
+	}
+
+	for _, file := range files {
+		filename := path.Base(file)
+
+		if !contains(filename, migrations) {
+			if previewing() {
+				fmt.Printf("would remove %s\n", file)
+				continue
+			}
+			err = os.Remove(file)
+			if err != nil {
+				fmt.Printf("Error removing migration %s :%s\n", file, err)
+				continue
+			}
+			fmt.Printf("Removed migration %s\n", file)
+			continue
+		}
+
+		// The migration has been run, so write another to revert it
+		sql, err := revertMigrationSQL(file)
+		if err != nil {
+			fmt.Printf("Error reading migration %s :%s\n", file, err)
+			continue
+		}
+		generateMigration(fmt.Sprintf("Destroy-%s", ToCamel(resourceName)), sql)
+	}
+}
+
+var createTableRegexp = regexp.MustCompile(`(?i)CREATE TABLE\s+(IF NOT EXISTS\s+)?(\w+)`)
+
+// revertMigrationSQL returns sql to drop every table created in the migration at file, in reverse order
+// Tables created if not exists may be shared with other resources, like the audit log, so are not dropped
+func revertMigrationSQL(file string) (string, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+
+	matches := createTableRegexp.FindAllStringSubmatch(string(data), -1)
+
+	sql := fmt.Sprintf("/* Revert migration %s */\n", path.Base(file))
+	for i := len(matches) - 1; i >= 0; i-- {
+		if matches[i][1] != "" {
+			continue
+		}
+		sql += fmt.Sprintf("DROP TABLE IF EXISTS %s;\n", matches[i][2])
+	}
+
+	return sql, nil
+}
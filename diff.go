@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+)
+
+// Lines of unchanged context shown around each change in a unified diff
+const diffContext = 3
+
+// writeGeneratedFile writes generated content to dst, respecting the --dry-run and --diff flags
+// If the file already exists with different content, the user is prompted to overwrite, skip or view a diff
+// It returns true if the file was written
+func writeGeneratedFile(dst string, content []byte) (bool, error) {
+	return writeFile(dst, content, true)
+}
+
+// overwriteGeneratedFile writes generated content to dst, respecting the --dry-run and --diff flags
+// but without prompting, for files which are always derived from others or which are edited in place, like routes.go
+func overwriteGeneratedFile(dst string, content []byte) (bool, error) {
+	return writeFile(dst, content, false)
+}
+
+// previewing returns true if changes should be listed rather than made, with --dry-run or --diff
+func previewing() bool {
+	return generateFlag("dry-run") || generateFlag("diff")
+}
+
+// writeFile writes content to dst unless previewing changes, and optionally prompts before overwriting a changed file
+func writeFile(dst string, content []byte, prompt bool) (bool, error) {
+
+	existing, err := ioutil.ReadFile(dst)
+	exists := err == nil
+
+	if exists && bytes.Equal(existing, content) {
+		fmt.Printf("identical %s\n", dst)
+		return false, nil
+	}
+
+	// If we are only previewing changes, report them and return without writing
+	if previewing() {
+		if exists {
+			fmt.Printf("would modify %s\n", dst)
+		} else {
+			fmt.Printf("would create %s\n", dst)
+		}
+		if generateFlag("diff") {
+			fmt.Print(unifiedDiff(dst, string(existing), string(content)))
+		}
+		return false, nil
+	}
+
+	// If the file exists, ask the user what to do with it
+	if exists && prompt && !confirmOverwrite(dst, string(existing), string(content)) {
+		fmt.Printf("skipped %s\n", dst)
+		return false, nil
+	}
+
+	// Make sure enclosing dir exists
+	os.MkdirAll(path.Dir(dst), permissions)
+
+	err = ioutil.WriteFile(dst, content, permissions)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// confirmOverwrite prompts the user to overwrite, skip or diff a conflicting file, and returns true for overwrite
+func confirmOverwrite(dst string, existing string, content string) bool {
+	for {
+		answer, err := promptForString(fmt.Sprintf("action for existing file %s [o]verwrite, [s]kip, [d]iff", dst))
+		if err != nil {
+			return false
+		}
+
+		switch strings.ToLower(answer) {
+		case "o", "overwrite":
+			return true
+		case "s", "skip":
+			return false
+		case "d", "diff":
+			fmt.Print(unifiedDiff(dst, existing, content))
+		}
+	}
+}
+
+// unifiedDiff returns a unified diff between the old and new versions of the file at name
+func unifiedDiff(name string, old string, new string) string {
+	a := splitLines(old)
+	b := splitLines(new)
+
+	// Build the longest common subsequence table, working back from the end of both files
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	// Walk the table to produce a list of edits
+	type edit struct {
+		op   byte
+		line string
+		a, b int // line indexes before this edit is applied
+	}
+	var edits []edit
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			edits = append(edits, edit{' ', a[i], i, j})
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] >= lcs[i+1][j]):
+			edits = append(edits, edit{'+', b[j], i, j})
+			j++
+		default:
+			edits = append(edits, edit{'-', a[i], i, j})
+			i++
+		}
+	}
+
+	// Group the edits into hunks with some context around each change
+	out := bytes.NewBufferString("")
+	fmt.Fprintf(out, "--- %s\n+++ %s\n", name, name)
+	for start := 0; start < len(edits); {
+		// Find the next change
+		for start < len(edits) && edits[start].op == ' ' {
+			start++
+		}
+		if start == len(edits) {
+			break
+		}
+
+		// Extend the hunk until we see more than twice the context of unchanged lines
+		end := start
+		for k := start; k < len(edits); k++ {
+			if edits[k].op != ' ' {
+				end = k + 1
+			} else if k-end >= diffContext*2 {
+				break
+			}
+		}
+
+		from := start - diffContext
+		if from < 0 {
+			from = 0
+		}
+		to := end + diffContext
+		if to > len(edits) {
+			to = len(edits)
+		}
+
+		aCount, bCount := 0, 0
+		for _, e := range edits[from:to] {
+			if e.op != '+' {
+				aCount++
+			}
+			if e.op != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(out, "@@ -%s +%s @@\n", hunkRange(edits[from].a, aCount), hunkRange(edits[from].b, bCount))
+		for _, e := range edits[from:to] {
+			fmt.Fprintf(out, "%c%s\n", e.op, e.line)
+		}
+
+		start = to
+	}
+
+	return out.String()
+}
+
+// hunkRange formats the start,count range of a hunk header
+func hunkRange(start int, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// splitLines splits text into lines, ignoring any final newline
+func splitLines(text string) []string {
+	if len(text) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
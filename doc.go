@@ -20,7 +20,24 @@ The following subcommands are available when using the command line fragmenta to
       fragmenta restore [development|production|test] -> backup the database from latest file in db/backup
       fragmenta deploy [development|production|test] -> build and deploy using bin/deploy
       fragmenta generate resource [name] [fieldname]:[fieldtype]* -> creates resource CRUD actions and views
+      fragmenta generate resource [name] --from-table [table] -> creates resource CRUD actions and views for an existing table
+      fragmenta generate resource [name] --parent [parent] [fieldname]:[fieldtype]* -> creates a resource nested under its parent e.g. /posts/{post_id}/comments
+      fragmenta generate api [name] [fieldname]:[fieldtype]* -> creates resource JSON api handlers and routes
+      fragmenta generate migration --auto -> creates a migration altering tables in the development db to match the model structs
+      fragmenta generate openapi -> writes an openapi spec for the app routes and resources to public/openapi.json
+      fragmenta generate resource [name] --auth [role] [fieldname]:[fieldtype]* -> creates a resource whose actions are restricted to users with role, see actions/policy.go
+      fragmenta generate resource [name] --soft-delete [fieldname]:[fieldtype]* -> creates a resource which is marked as deleted by destroy, and may be restored
+      fragmenta generate resource [name] --audit [fieldname]:[fieldtype]* -> creates a resource which records the users who change it, with each change logged to audit_logs
+      fragmenta generate resource [name] --uuid [fieldname]:[fieldtype]* -> creates a resource with a uuid primary key, rather than a serial integer
+      fragmenta generate auth -> creates a users resource with login, logout and password resets
+      fragmenta generate admin -> creates an admin at /admin listing the records of every resource, kept up to date as resources are generated
       fragmenta generate migration [name] -> creates a new named sql migration in db/migrate
+      fragmenta templates eject [name]* -> copies the default generator templates to src/lib/templates for customisation
+      fragmenta routes -> lists the routes in the app routes file and warns about duplicate or shadowed routes
+      fragmenta destroy resource [name] -> removes a generated resource, its routes and its migration
+      fragmenta generate [generator] [name] [arguments]* -> runs a named generator from src/lib/templates/[generator]
+      fragmenta generate ... --dry-run -> lists the files generate would create or modify without writing them
+      fragmenta generate ... --diff -> shows a unified diff of each change generate would make without writing them
     ------
 
 
@@ -55,6 +72,6 @@ otherwise the structure of your app is up to you. The default structure given in
         pages.go -> the pages model
         pages_test.go -> tests for the pages model
 
-These are suggested patterns and can be overridden by editing the files in lib/template in your project, which define exactly what is generated by fragmenta generate. 
+These are suggested patterns and can be overridden by editing the files in src/lib/templates in your project, which define exactly what is generated by fragmenta generate. If your project has no templates, the default templates built in to fragmenta are used - run fragmenta templates eject to copy them into your project. 
 */
 package main
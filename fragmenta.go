@@ -14,6 +14,7 @@ import (
 	"os/exec"
 	"path"
 	"path/filepath"
+	"strings"
 )
 
 const (
@@ -33,6 +34,12 @@ var (
 
 	// ConfigTest holds the app test config from fragmenta.json
 	ConfigTest map[string]string
+
+	// ConfigTypes holds any extra field types for generate from fragmenta.json, see registerConfigTypes
+	ConfigTypes map[string]string
+
+	// ConfigInflections holds any plurals for generate from fragmenta.json, see registerInflections
+	ConfigInflections map[string]string
 )
 
 // main - Parse the command line arguments and respond
@@ -94,6 +101,21 @@ func main() {
 			RunGenerate(args)
 		}
 
+	case "templates":
+		if requireValidProject(projectPath) {
+			RunTemplates(args)
+		}
+
+	case "routes":
+		if requireValidProject(projectPath) {
+			RunRoutes(args)
+		}
+
+	case "destroy":
+		if requireValidProject(projectPath) {
+			RunDestroy(args)
+		}
+
 	case "migrate", "m":
 		if requireValidProject(projectPath) {
 			RunMigrate(args)
@@ -147,7 +169,32 @@ func ShowHelp(args []string) {
 	helpString += "\n  fragmenta restore [development|production|test] -> backup the database from latest file in db/backup"
 	helpString += "\n  fragmenta deploy [development|production|test] -> build and deploy using bin/deploy"
 	helpString += "\n  fragmenta generate resource [name] [fieldname]:[fieldtype]* -> creates resource CRUD actions and views"
+	helpString += "\n  fragmenta generate resource [name] --from-table [table] -> creates resource CRUD actions and views for an existing table"
+	helpString += "\n  fragmenta generate resource [name] --parent [parent] [fieldname]:[fieldtype]* -> creates a resource nested under its parent e.g. /posts/{post_id}/comments"
+	helpString += "\n  fragmenta generate api [name] [fieldname]:[fieldtype]* -> creates resource JSON api handlers and routes"
+	helpString += "\n  fragmenta generate migration --auto -> creates a migration altering tables in the development db to match the model structs"
+	helpString += "\n  fragmenta generate openapi -> writes an openapi spec for the app routes and resources to public/openapi.json"
+	helpString += "\n  fragmenta generate resource [name] --auth [role] [fieldname]:[fieldtype]* -> creates a resource whose actions are restricted to users with role, see actions/policy.go"
+	helpString += "\n  fragmenta generate resource [name] --soft-delete [fieldname]:[fieldtype]* -> creates a resource which is marked as deleted by destroy, and may be restored"
+	helpString += "\n  fragmenta generate resource [name] --audit [fieldname]:[fieldtype]* -> creates a resource which records the users who change it, with each change logged to audit_logs"
+	helpString += "\n  fragmenta generate resource [name] --uuid [fieldname]:[fieldtype]* -> creates a resource with a uuid primary key, rather than a serial integer"
+	helpString += "\n  fragmenta generate auth -> creates a users resource with login, logout and password resets"
+	helpString += "\n  fragmenta generate admin -> creates an admin at /admin listing the records of every resource, kept up to date as resources are generated"
 	helpString += "\n  fragmenta generate migration [name] -> creates a new named sql migration in db/migrate"
+	helpString += "\n  fragmenta templates eject [name]* -> copies the default generator templates to src/lib/templates for customisation"
+	helpString += "\n  fragmenta routes -> lists the routes in the app routes file and warns about duplicate or shadowed routes"
+	helpString += "\n  fragmenta destroy resource [name] -> removes a generated resource, its routes and its migration"
+	helpString += "\n  fragmenta generate [generator] [name] [arguments]* -> runs a named generator from src/lib/templates/[generator]"
+	helpString += "\n  fragmenta generate ... --dry-run -> lists the files generate would create or modify without writing them"
+	helpString += "\n  fragmenta generate ... --diff -> shows a unified diff of each change generate would make without writing them"
+
+	// Show any named generators defined by this project
+	for _, name := range appGenerators() {
+		g, err := readGenerator(name)
+		if err == nil && g != nil {
+			helpString += "\n" + strings.TrimRight(generatorUsage(name, g), "\n")
+		}
+	}
 
 	helpString += fragmentaDivider
 	log.Print(helpString)
@@ -191,10 +238,6 @@ func secretsPath(projectPath string) string {
 	return projectPath + "/secrets"
 }
 
-func templatesPath() string {
-	return os.ExpandEnv("$GOPATH/src/github.com/fragmenta/fragmenta/templates")
-}
-
 // RunServer runs the server
 func RunServer(projectPath string) {
 	ShowVersion()
@@ -309,6 +352,20 @@ func readConfig(projectPath string) error {
 	ConfigDevelopment = data["development"]
 	ConfigProduction = data["production"]
 	ConfigTest = data["test"]
+	ConfigTypes = data["types"]
+	ConfigInflections = data["inflections"]
+
+	err = registerConfigTypes(ConfigTypes)
+	if err != nil {
+		log.Printf("Error parsing types in config %s %v", configPath, err)
+		return err
+	}
+
+	err = registerInflections(projectPath, ConfigInflections)
+	if err != nil {
+		log.Printf("Error reading inflections %v", err)
+		return err
+	}
 
 	return nil
 }
@@ -2,12 +2,17 @@ package main
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/fs"
 	"io/ioutil"
 	"log"
 	"os"
 	"path"
-	"path/filepath"
 	"sort"
 	"strings"
 	"text/template"
@@ -22,67 +27,158 @@ const (
 var resourceName string
 var columns map[string]string
 
+// resourceTable holds the table name for the resource if it is not the plural of the name, see resourceTableName
+var resourceTable string
+
+// resourceParent holds the name of the parent resource set with --parent, for resources nested under another
+var resourceParent string
+
+// hiddenColumns lists columns which are set by code rather than params, so are not shown in forms or views,
+// accepted as params or used in tests, e.g. the password hash of users generated by generate auth
+var hiddenColumns []string
+
+// columnModifiers holds any modifiers given after the type of a column, e.g. title:text:searchable
+var columnModifiers map[string][]string
+
+// generateCommandsWithoutArgs lists the generate commands which do not require a name
+var generateCommandsWithoutArgs = []string{"openapi", "auth", "admin"}
+
+// generateFlags holds any --flags passed to generate, keyed by name without the leading dashes
+var generateFlags map[string]string
+
+// generateValueFlags may be given a value as the following arg e.g. --parent post, as well as with --parent=post
+var generateValueFlags = []string{"parent", "auth", "from-table"}
+
 // RunGenerate runs the generate command
 // Expects:
 // - generate migration
 // - generate resource pages name:text summary:text
+// Any command may be followed by --dry-run or --diff to preview changes without writing them
 func RunGenerate(args []string) {
 	// Remove fragmenta generate from args list
-	args = args[2:]
+	args = parseGenerateFlags(args[2:])
 
-	if len(args) < 2 {
+	if len(args) < 1 {
 		fmt.Println("Not enough arguments")
 		return
 	}
 	command := args[0]
 	args = args[1:]
+
+	// Most commands require at least a name, apart from generate migration --auto
+	autoMigration := command == "migration" && generateFlag("auto")
+	if len(args) < 1 && !contains(command, generateCommandsWithoutArgs) && !autoMigration {
+		fmt.Println("Not enough arguments")
+		return
+	}
+
 	switch command {
 	case "migration":
+		if generateFlag("auto") {
+			generateAutoMigration()
+			return
+		}
 		name := args[0]
 		sql := fmt.Sprintf("/* SQL migration %s */", name)
 		generateMigration(name, sql)
 	case "resource":
-		generateResource(args)
+		if generateFlag("from-table") {
+			generateResourceFromTable(args)
+		} else {
+			generateResource(args)
+		}
+	case "api":
+		generateAPI(args)
+	case "openapi":
+		generateOpenAPI()
+	case "auth":
+		generateAuth()
+	case "admin":
+		generateAdmin()
 	case "join":
 		if len(args) < 2 {
 			fmt.Println("Error - not enough arguments for join table")
 			return
 		}
+		args = []string{ToSingular(args[0]), ToSingular(args[1])}
 		sort.Strings(args)
 		name := fmt.Sprintf("%s-%s", args[0], args[1])
 		sql := generateJoinSQL(args)
 		generateMigration(name, sql)
 	default:
-		fmt.Println("Sorry, I didn't recognise that argument, you can use fragmenta generate [migration|resource|join]")
+		// Look for a named generator in the app templates
+		if generateNamed(command, args) {
+			return
+		}
+		generators := append([]string{"migration", "resource", "api", "join", "openapi", "auth", "admin"}, appGenerators()...)
+		fmt.Printf("Sorry, I didn't recognise that argument, you can use fragmenta generate [%s]\n", strings.Join(generators, "|"))
 	}
 }
 
 // Generate the scaffold for a new REST resource
 func generateResource(args []string) {
 
+	joinSQL, err := parseResourceArgs(args)
+	if err != nil {
+		fmt.Printf("Error generating resource %s\n", err)
+		return
+	}
+
+	// First db migration
+	generateResourceMigration(joinSQL)
+
+	// Then generate routes
+	generateResourceRoutes("")
+
+	// Then copy files from templates dir over to src/resourceName
+	generateResourceFiles()
+
+	// Then generate tests for the model and actions
+	generateResourceTests()
+
+	// Update the api docs to include the new routes
+	generateOpenAPI()
+
+	// Finally add the resource to the admin, if the app has one
+	syncAdmin()
+
+}
+
+// parseResourceArgs sets resourceName and columns from args, and returns sql for any join tables requested
+// an error is returned if any column has an unknown type
+func parseResourceArgs(args []string) (string, error) {
+
 	// Extract the keys from args
 	// args should be using snake case, which we will convert to camel case as necc.
 	resourceName = ""
+	resourceParent = ""
+	hiddenColumns = nil
 
 	columns = make(map[string]string, 0)
+	columnModifiers = make(map[string][]string, 0)
 	var joins []string
 
 	for _, v := range args {
 
 		if len(resourceName) == 0 {
-			resourceName = strings.ToLower(v)
+			resourceName = ToSingular(v)
 		} else {
 			parts := strings.Split(v, ":")
-			if len(parts) == 2 {
+			if len(parts) >= 2 {
 				key := strings.ToLower(parts[0])
 				value := strings.ToLower(parts[1])
 
 				if key == "joins" {
 					// We have a list of joins, potentially separated by ,
-					joins = strings.Split(value, ",")
+					for _, j := range strings.Split(value, ",") {
+						joins = append(joins, ToSingular(j))
+					}
 				} else {
-					// Add a normal column
+					// Add a normal column, with any modifiers which follow the type
 					columns[key] = value
+					for _, m := range parts[2:] {
+						columnModifiers[key] = append(columnModifiers[key], strings.ToLower(m))
+					}
 				}
 
 			} else {
@@ -92,7 +188,40 @@ func generateResource(args []string) {
 
 	}
 
-	// NB we expect to start with a lower case singular
+	err := checkFieldTypes(columns)
+	if err != nil {
+		return "", err
+	}
+
+	err = checkSearchable()
+	if err != nil {
+		return "", err
+	}
+
+	// Nested resources have a column for the id of their parent
+	parent := generateFlagValue("parent")
+	if parent != "" && parent != "true" {
+		resourceParent = ToSingular(parent)
+		columns[resourceParent+"_id"] = keyFieldType(resourceParent)
+	}
+
+	// Soft deleted resources have a deleted_at column, which is set by Destroy instead of deleting the row
+	if generateFlag("soft-delete") {
+		columns["deleted_at"] = "time"
+		hiddenColumns = append(hiddenColumns, "deleted_at")
+	}
+
+	// Audited resources record the users who create and update them, so require users
+	if generateFlag("audit") {
+		if !hasAuth() {
+			return "", errors.New("--audit records the current user, run fragmenta generate auth first")
+		}
+		columns["created_by"] = keyFieldType("user")
+		columns["updated_by"] = keyFieldType("user")
+		hiddenColumns = append(hiddenColumns, "created_by", "updated_by")
+	}
+
+	// NB the name and parent may be given as singular or plural, and are converted to singular
 	fmt.Printf("Generating resource with\n - name:%s\n - attributes:%v\n", resourceName, columns)
 
 	joinSQL := ""
@@ -103,32 +232,82 @@ func generateResource(args []string) {
 
 	}
 
-	// First db migration
-	generateResourceMigration(joinSQL)
+	return joinSQL, nil
+}
 
-	// Then generate routes
-	generateResourceRoutes()
+// Generate the routes required and insert them into the routes.go file, along with any extra routes for the resource actions
+func generateResourceRoutes(extraRoutes string) {
+	resourceRoutes := reifyString(extraRoutes + resourceRoutesTemplate)
+	resourceImport := reifyString("[[.fragmenta_app_path]]/[[.fragmenta_resources]]/actions")
 
-	// Then finally copy files from templates dir over to src/resourceName
-	generateResourceFiles()
+	addRoutes(resourceRoutes, resourceImport)
+}
 
+// The routes added for each resource by generate resource
+// TODO - this routesTemplate should be a file
+const resourceRoutesTemplate = `
+    r.Add("[[.fragmenta_route_path]]", [[.fragmenta_resource]]actions.HandleIndex)
+    r.Add("[[.fragmenta_route_path]]/create", [[.fragmenta_resource]]actions.HandleCreateShow)
+    r.Add("[[.fragmenta_route_path]]/create", [[.fragmenta_resource]]actions.HandleCreate).Post()
+    r.Add("[[.fragmenta_route_path]]/{id:[[.fragmenta_id_pattern]]}/update", [[.fragmenta_resource]]actions.HandleUpdateShow)
+    r.Add("[[.fragmenta_route_path]]/{id:[[.fragmenta_id_pattern]]}/update", [[.fragmenta_resource]]actions.HandleUpdate).Post()
+    r.Add("[[.fragmenta_route_path]]/{id:[[.fragmenta_id_pattern]]}/destroy", [[.fragmenta_resource]]actions.HandleDestroy).Post()
+[[- if .fragmenta_soft_delete ]]
+    r.Add("[[.fragmenta_route_path]]/{id:[[.fragmenta_id_pattern]]}/restore", [[.fragmenta_resource]]actions.HandleRestore).Post()
+[[- end ]]
+    r.Add("[[.fragmenta_route_path]]/{id:[[.fragmenta_id_pattern]]}", [[.fragmenta_resource]]actions.HandleShow)`
+
+// Generate the path of the resource routes, nested under the parent if there is one e.g. /posts/{post_id:[0-9]+}/comments
+func resourceRoutePath() string {
+	if resourceParent == "" {
+		return "/" + ToPlural(resourceName)
+	}
+	return fmt.Sprintf("/%s/{%s_id:%s}/%s", ToPlural(resourceParent), resourceParent, idPattern(resourceParent), ToPlural(resourceName))
 }
 
-// Generate the routes required and insert them into the routes.go file
-func generateResourceRoutes() {
+// Generate the url of the resource in views, nested under the parent if there is one
+// record views use the parent id of the resource e.g. /posts/{{ .comment.PostID }}/comments,
+// while the index view uses the parent e.g. /posts/{{ $.post.Id }}/comments
+func resourceURL(record bool) string {
+	if resourceParent == "" {
+		return "/" + ToPlural(resourceName)
+	}
+	return fmt.Sprintf("/%s/%s/%s", ToPlural(resourceParent), parentID(record), ToPlural(resourceName))
+}
 
-	// TODO - this routesTemplate should be a file
-	routesTemplate := `
-    r.Add("/[[.fragmenta_resources]]", [[.fragmenta_resource]]actions.HandleIndex)
-    r.Add("/[[.fragmenta_resources]]/create", [[.fragmenta_resource]]actions.HandleCreateShow)
-    r.Add("/[[.fragmenta_resources]]/create", [[.fragmenta_resource]]actions.HandleCreate).Post()
-    r.Add("/[[.fragmenta_resources]]/{id:[0-9]+}/update", [[.fragmenta_resource]]actions.HandleUpdateShow)
-    r.Add("/[[.fragmenta_resources]]/{id:[0-9]+}/update", [[.fragmenta_resource]]actions.HandleUpdate).Post()
-    r.Add("/[[.fragmenta_resources]]/{id:[0-9]+}/destroy", [[.fragmenta_resource]]actions.HandleDestroy).Post()
-    r.Add("/[[.fragmenta_resources]]/{id:[0-9]+}", [[.fragmenta_resource]]actions.HandleShow)`
+// Generate the parent id for views, see resourceURL
+func parentID(record bool) string {
+	if record {
+		return fmt.Sprintf("{{ .%s.%s }}", resourceName, ToCamel(resourceParent+"_id"))
+	}
+	return fmt.Sprintf("{{ $.%s.Id }}", resourceParent)
+}
 
-	resourceRoutes := reifyString(routesTemplate)
+// Generate breadcrumb links back to the parent for views, or an empty string if there is no parent
+func breadcrumbs(record bool) string {
+	if resourceParent == "" {
+		return ""
+	}
 
+	tmpl := `  <p class="breadcrumbs">
+    <a href="/[[.parents]]">[[.Parents]]</a> /
+    <a href="/[[.parents]]/[[.parent_id]]">[[.Parent]] [[.parent_id]]</a> /
+    <a href="[[.url]]">[[.Fragmenta_Resources]]</a>
+  </p>
+`
+	context := map[string]string{
+		"parents":             ToPlural(resourceParent),
+		"Parents":             ToCamel(ToPlural(resourceParent)),
+		"Parent":              ToCamel(resourceParent),
+		"parent_id":           parentID(record),
+		"url":                 resourceURL(record),
+		"Fragmenta_Resources": ToCamel(ToPlural(resourceName)),
+	}
+	return renderTemplate(tmpl, context)
+}
+
+// addRoutes inserts routes at the start of the routes function in the routes.go file, and adds an import for their actions
+func addRoutes(routes string, importPath string) {
 	routesPath := appRoutesFilePath()
 	data, err := ioutil.ReadFile(routesPath)
 	if err != nil {
@@ -138,28 +317,98 @@ func generateResourceRoutes() {
 
 	fmt.Println("Generating resource routes at: ", routesPath)
 
-	routes := string(data)
-
-	if strings.Contains(routes, ToPlural(resourceName)+"/actions") {
+	output, err := insertRoutes(data, appRoutesFunction(), routes, importPath)
+	if err == errRoutesExist {
 		fmt.Println("Routes already exist for resource: ", resourceName)
 		return
 	}
+	if err != nil {
+		fmt.Printf("#error Error generating routes at:%s :%s\n", routesPath, err)
+		return
+	}
 
-	routesStart := "func setupRoutes(r *router.Router) {"
-	routes = strings.Replace(routes, routesStart, routesStart+"\n"+resourceRoutes, 1)
-
-	resourceImport := reifyString("\n\t\"[[.fragmenta_app_path]]/[[.fragmenta_resources]]/actions\"")
-	importStart := "import ("
-	routes = strings.Replace(routes, importStart, importStart+resourceImport, 1)
-
-	err = ioutil.WriteFile(routesPath, []byte(routes), permissions)
+	// The routes file is edited rather than replaced, so is written without prompting
+	written, err := overwriteGeneratedFile(routesPath, output)
 	if err != nil {
 		fmt.Println("Error writing routes file: ", routesPath)
 		return
 	}
 
-	fmt.Println("Generated resource routes")
+	if written {
+		fmt.Println("Generated resource routes")
+	}
+
+}
+
+// errRoutesExist is returned by insertRoutes if the routes file already imports the actions for the routes
+var errRoutesExist = errors.New("routes already exist")
+
+// insertRoutes parses the go source of a routes file, and inserts routes at the start of the function funcName
+// and an import of importPath, returning the formatted source
+// Routes are written using r as the router, this is replaced with the name of the router param of funcName
+func insertRoutes(src []byte, funcName string, routes string, importPath string) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "routes.go", src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, i := range file.Imports {
+		if strings.Trim(i.Path.Value, "\"") == importPath {
+			return nil, errRoutesExist
+		}
+	}
+
+	// Find the routes function
+	var routesFunc *ast.FuncDecl
+	for _, d := range file.Decls {
+		f, ok := d.(*ast.FuncDecl)
+		if ok && f.Recv == nil && f.Name.Name == funcName {
+			routesFunc = f
+		}
+	}
+	if routesFunc == nil || routesFunc.Body == nil {
+		return nil, fmt.Errorf("no func %s found in routes file - set routes_function in config to the func which adds routes", funcName)
+	}
+	params := routesFunc.Type.Params.List
+	if len(params) == 0 || len(params[0].Names) == 0 {
+		return nil, fmt.Errorf("func %s has no router param to add routes to", funcName)
+	}
+	router := params[0].Names[0].Name
+	if router != "r" {
+		routes = strings.Replace(routes, "r.Add(", router+".Add(", -1)
+	}
+
+	// Insert routes after the opening brace of the func, and the import after the opening paren of the imports,
+	// or after the package clause if there is no import block - as routes come after imports, insert them first
+	output := insertAt(src, fset.Position(routesFunc.Body.Lbrace).Offset+1, "\n"+routes+"\n")
+
+	importOffset := fset.Position(file.Name.End()).Offset
+	importSpec := fmt.Sprintf("\n\nimport %q", importPath)
+	for _, d := range file.Decls {
+		g, ok := d.(*ast.GenDecl)
+		if ok && g.Tok == token.IMPORT && g.Lparen.IsValid() {
+			importOffset = fset.Position(g.Lparen).Offset + 1
+			importSpec = fmt.Sprintf("\n\t%q", importPath)
+			break
+		}
+	}
+	output = insertAt(output, importOffset, importSpec)
+
+	output, err = format.Source(output)
+	if err != nil {
+		return nil, fmt.Errorf("error formatting routes %s", err)
+	}
+
+	return output, nil
+}
 
+// insertAt returns a copy of src with text inserted at offset
+func insertAt(src []byte, offset int, text string) []byte {
+	output := make([]byte, 0, len(src)+len(text))
+	output = append(output, src[:offset]...)
+	output = append(output, text...)
+	return append(output, src[offset:]...)
 }
 
 // Generate SQL for a join table migration
@@ -177,8 +426,8 @@ func generateJoinSQL(args []string) string {
 	sql := `
 DROP TABLE IF EXISTS [[.join_table]];
 CREATE TABLE [[.join_table]] (
-[[.a]]_id int NOT NULL,
-[[.b]]_id int NOT NULL
+[[.a]]_id [[.a_type]] NOT NULL,
+[[.b]]_id [[.b_type]] NOT NULL
 );
 `
 
@@ -186,6 +435,8 @@ CREATE TABLE [[.join_table]] (
 		"join_table": ToPlural(a) + "_" + ToPlural(b), // e.g. places_tags
 		"a":          a,                               // places
 		"b":          b,                               // tags
+		"a_type":     toSQLType(keyFieldType(a)),      // integer, or uuid for resources with uuid keys
+		"b_type":     toSQLType(keyFieldType(b)),
 	}
 
 	return renderTemplate(sql, context)
@@ -196,21 +447,26 @@ CREATE TABLE [[.join_table]] (
 func generateResourceMigration(joinsSQL string) {
 
 	// We add the following fields to all resourceNames
-	sql := `DROP TABLE IF EXISTS [[.fragmenta_resources]];
+	sql := uuidExtensionSQL() + `DROP TABLE IF EXISTS [[.fragmenta_resources]];
 CREATE TABLE [[.fragmenta_resources]] (
-id SERIAL NOT NULL,
+[[.fragmenta_primary_key]],
 created_at timestamp,
 updated_at timestamp,
 `
 
 	for k, v := range columns {
-		sql = sql + fmt.Sprintf("%s %s,\n", k, toSQLType(v))
+		sql = sql + fmt.Sprintf("%s %s%s,\n", k, toSQLType(v), enumCheckSQL(k, v))
 	}
 
 	sql = sql + ");\n"
 	sql = strings.Replace(sql, ",\n)", "\n)", -1)
 
 	sql += "ALTER TABLE [[.fragmenta_resources]] OWNER TO [[.fragmenta_db_user]];\n"
+	if resourceParent != "" {
+		sql += fmt.Sprintf("CREATE INDEX ON [[.fragmenta_resources]] (%s_id);\n", resourceParent)
+	}
+	sql += searchMigrationSQL()
+	sql += auditMigrationSQL()
 
 	sql = reifyString(sql)
 
@@ -221,6 +477,20 @@ updated_at timestamp,
 
 }
 
+// Generate a check constraint limiting an enum column to its values, or an empty string for other types
+func enumCheckSQL(col string, fieldType string) string {
+	values := fieldEnumValues(fieldType)
+	if len(values) == 0 {
+		return ""
+	}
+
+	var quoted []string
+	for _, v := range values {
+		quoted = append(quoted, "'"+strings.Replace(v, "'", "''", -1)+"'")
+	}
+	return fmt.Sprintf(" CHECK (%s IN (%s))", col, strings.Join(quoted, ","))
+}
+
 // Return the path of the routes.go file
 func appRoutesFilePath() string {
 	// Find the routes.go file, and add the routes at the start of setRoutes()
@@ -234,6 +504,16 @@ func appRoutesFilePath() string {
 	return routesPath
 }
 
+// Return the name of the func in the routes.go file which adds routes to the router
+func appRoutesFunction() string {
+	routesFunction := ConfigDevelopment["routes_function"]
+	if len(routesFunction) == 0 {
+		routesFunction = "setupRoutes"
+	}
+
+	return routesFunction
+}
+
 func appGeneratePath() string {
 	codePath := ConfigDevelopment["path_generate"]
 	if len(codePath) == 0 {
@@ -255,121 +535,157 @@ func appServerName() string {
 	return path.Base(ConfigDevelopment["path"])
 }
 
-func appTemplatesPath() string {
-	return path.Join(fullAppPath(), "src", "lib", "templates", "fragmenta_resources")
+// appTemplateSetPath returns the path of the named set of templates within the app
+func appTemplateSetPath(name string) string {
+	return path.Join(fullAppPath(), "src", "lib", "templates", name)
 }
 
 func generateResourceFiles() {
 
-	srcPath := appTemplatesPath()
-
-	// Try to use local templates, if not use the fragmenta default templates
-	_, err := os.Stat(srcPath)
-	if err != nil {
-		// Use our internal templates path instead (inside the fragmenta package)
-		log.Printf("No template files at %s", srcPath)
-		//	srcPath = path.Join(templatesPath(), "fragmenta_resources")
-	}
+	templates := templateSet("fragmenta_resources")
 
-	log.Printf("Using templates at %s", srcPath)
+	// Tests are generated by generateResourceTests instead
+	skipTestTemplates = true
+	defer func() { skipTestTemplates = false }()
 
 	// For a destination, use the set path or default to ./src/xxx
 	dstPath := path.Join(fullAppPath(), appGeneratePath(), ToPlural(resourceName))
 
 	fmt.Printf("Creating files at %s\n", dstPath)
-	copyAndReifyFiles(srcPath, dstPath)
+	copyAndReifyFiles(templates, dstPath)
 
 }
 
-func copyAndReifyFiles(srcPath string, dstPath string) error {
-	var err error
+// Generate table driven tests for the resource model and httptest tests for the resource routes
+func generateResourceTests() {
+	templates := templateSet("fragmenta_tests")
 
-	//log.Printf(" %s =>\n", srcPath)
+	dstPath := path.Join(fullAppPath(), appGeneratePath(), ToPlural(resourceName))
+	copyAndReifyFiles(templates, dstPath)
 
-	// Get info on the src
-	srcInfo, err := os.Stat(srcPath)
-	if err != nil {
-		log.Fatal("Error statting src path ", srcPath)
-		return err
+	// The actions tests are in their own package, so need their own copy of the test helpers
+	if _, err := fs.Stat(templates, testHelpersTemplate); err == nil {
+		testPackageDir = "actions"
+		defer func() { testPackageDir = "" }()
+		copyAndReifyFile(templates, testHelpersTemplate, path.Join(dstPath, testPackageDir))
 	}
+}
 
-	// If this is a directory, copy every file within the src folder over to dst folder
-	if srcInfo.IsDir() {
+// The template of the helpers shared by the tests in each package of a resource
+const testHelpersTemplate = "helpers_test.go.tmpl"
 
-		err = filepath.Walk(srcPath, func(fileSrc string, info os.FileInfo, err error) error {
-			fileDst := dstPath
+// testPackageDir is set to the directory within the resource of the package the test helpers are generated for,
+// or is empty for the resource package itself
+var testPackageDir string
 
-			// split the srcPath on 'fragmenta_resources'
-			// and use everything after that as the dst path
-			srcParts := strings.Split(fileSrc, "/fragmenta_resources/")
-			if len(srcParts) == 2 {
-				fileDst = path.Join(dstPath, srcParts[1])
-			}
+// testPackage returns the name of the package the test helpers are generated for
+func testPackage() string {
+	if testPackageDir == "actions" {
+		return resourceName + "actions_test"
+	}
+	return ToPlural(resourceName)
+}
+
+// testRootPath returns the path of the app root relative to the package the test helpers are generated for
+func testRootPath() string {
+	if testPackageDir != "" {
+		return path.Join(rootPathFromResource(), "..")
+	}
+	return rootPathFromResource()
+}
 
-			fileDst = reifyName(fileDst)
+// skipTestTemplates is set while copying resource templates, as tests are generated separately
+var skipTestTemplates bool
 
-			// Do not operate on dot files
-			if strings.HasPrefix(path.Base(fileSrc), ".") && path.Base(fileSrc) != ".keep" {
-				return nil
-			}
+// copyAndReifyFiles copies every file in templates over to dstPath, reifying the file names and contents
+func copyAndReifyFiles(templates fs.FS, dstPath string) error {
+
+	return fs.WalkDir(templates, ".", func(fileSrc string, d fs.DirEntry, err error) error {
+		if err != nil {
+			log.Fatal("Error reading templates ", err)
+			return err
+		}
 
-			// If this entry is a dir, just make sure it exists
-			if info.IsDir() {
-				os.MkdirAll(dstPath, permissions)
-				return nil
+		// Do not operate on dot files
+		if strings.HasPrefix(d.Name(), ".") && fileSrc != "." && d.Name() != ".keep" {
+			if d.IsDir() {
+				return fs.SkipDir
 			}
+			return nil
+		}
 
-			// If this entry is a file, recurse and reify the file
-			return copyAndReifyFiles(fileSrc, fileDst)
+		// Directories are created as required when writing files
+		if d.IsDir() {
+			return nil
+		}
 
-		})
+		// The manifest of a named generator is not a template
+		if fileSrc == generatorManifest {
+			return nil
+		}
 
-		return nil
-	}
+		return copyAndReifyFile(templates, fileSrc, dstPath)
+	})
 
-	// If the file already exists, we should probably prompt the user as to whether they want to overwrite?
-	// We shouldn't overwrite by default as here...
+}
 
-	// Print file destinations without prefix of time on log, to make them stand out
-	log.Printf("=> %s\n", dstPath)
+// copyAndReifyFile copies the file fileSrc in templates to the same path within dstPath, reifying the file name and contents
+func copyAndReifyFile(templates fs.FS, fileSrc string, dstPath string) error {
+
+	// Use the path of this entry within the templates as the dst path
+	fileDst := reifyName(path.Join(dstPath, fileSrc))
+
+	// Tests are generated separately from the resource templates, see generateResourceTests
+	if skipTestTemplates && strings.HasSuffix(fileDst, "_test.go") {
+		log.Printf("Skipping template %s, tests are generated", fileSrc)
+		return nil
+	}
 
 	// Read the file
-	template, err := ioutil.ReadFile(srcPath)
+	template, err := fs.ReadFile(templates, fileSrc)
 	if err != nil {
-		log.Fatal("Error reading file ", srcPath)
+		log.Fatal("Error reading file ", fileSrc)
 	}
 
 	// Substitutions
 	output := reifyString(string(template))
 
-	// Make sure enclosing dir exists
-	os.MkdirAll(path.Dir(dstPath), permissions)
+	// Templates may render nothing if they are not required for this resource e.g. actions/uploads.go
+	if strings.TrimSpace(output) == "" {
+		return nil
+	}
 
-	// Now write out again at same path
-	err = ioutil.WriteFile(dstPath, []byte(output), permissions)
+	// Now write out again at same path - if the file already exists the user is prompted before overwriting
+	written, err := writeGeneratedFile(fileDst, []byte(output))
 	if err != nil {
-		log.Fatal("Error writing file ", dstPath)
+		log.Fatal("Error writing file ", fileDst)
 	}
 
-	return err
+	// Print file destinations without prefix of time on log, to make them stand out
+	if written {
+		log.Printf("=> %s\n", fileDst)
+	}
 
+	return nil
 }
 
 // Render a template to a string with a given context
-func renderTemplate(tmpl string, context map[string]string) string {
+// Templates may use the helper functions in templateFuncs
+func renderTemplate(tmpl string, context interface{}) string {
 
 	t := template.New("fields")
 	t.Delims("[[", "]]")
+	t.Funcs(templateFuncs)
 	t, err := t.Parse(tmpl)
 	if err != nil {
-		log.Printf("Error creating fields template")
+		log.Printf("Error creating fields template %s", err)
 		return ""
 	}
 
 	var rendered bytes.Buffer
 	err = t.Execute(&rendered, context)
 	if err != nil {
-		log.Printf("Error rendering fields template")
+		log.Printf("Error rendering fields template %s", err)
 		return ""
 	}
 
@@ -380,6 +696,10 @@ func renderTemplate(tmpl string, context map[string]string) string {
 // users.Id = validate.Int(cols["id"])
 func newFields() string {
 	tmpl := "\t[[.fragmenta_resource]].[[.field_name]] = validate.[[.validate_type]](cols[\"[[.col_name]]\"])\n"
+
+	// Arrays are read from the database as a literal like {a,b}
+	arrayTmpl := "\t[[.fragmenta_resource]].[[.field_name]] = strings.FieldsFunc(strings.Trim(validate.String(cols[\"[[.col_name]]\"]), \"{}\"), func(r rune) bool { return r == ',' })\n"
+
 	fields := ""
 	for _, k := range sortedKeys(columns) {
 		fieldContext := map[string]string{
@@ -389,6 +709,11 @@ func newFields() string {
 			"validate_type":      toValidateType(columns[k]),
 		}
 
+		if toGoType(columns[k]) == "[]string" {
+			fields += renderTemplate(arrayTmpl, fieldContext)
+			continue
+		}
+
 		fields += renderTemplate(tmpl, fieldContext)
 
 	}
@@ -418,9 +743,28 @@ func structFields() string {
 // Generate show page fields for our columns
 func showFields() string {
 	tmpl := "\t<p>[[.field_name]]: {{ .[[.fragmenta_resource]].[[.field_name]] }}</p>\n"
+
+	// Uploaded files are linked to, and images shown as a thumbnail linking to the full image
+	fileTmpl := "\t<p>[[.field_name]]: <a href=\"{{ .[[.fragmenta_resource]].[[.field_name]] }}\">{{ .[[.fragmenta_resource]].[[.field_name]] }}</a></p>\n"
+	imageTmpl := "\t<p>[[.field_name]]: <a href=\"{{ .[[.fragmenta_resource]].[[.field_name]] }}\"><img src=\"{{ .[[.fragmenta_resource]].[[.field_name]]Thumbnail }}\" alt=\"[[.field_name]]\"></a></p>\n"
+
+	// Arrays are shown as a comma separated list
+	arrayTmpl := "\t<p>[[.field_name]]: {{ .[[.fragmenta_resource]].[[.field_name]]Text }}</p>\n"
+
 	fields := ""
 
-	for _, k := range sortedKeys(columns) {
+	for _, k := range visibleColumns() {
+		fieldTmpl := tmpl
+		switch columns[k] {
+		case "file":
+			fieldTmpl = fileTmpl
+		case "image":
+			fieldTmpl = imageTmpl
+		}
+		if toGoType(columns[k]) == "[]string" {
+			fieldTmpl = arrayTmpl
+		}
+
 		fieldContext := map[string]string{
 			"fragmenta_resources": ToPlural(resourceName),
 			"fragmenta_resource":  resourceName,
@@ -428,17 +772,181 @@ func showFields() string {
 			"Fragmenta_Resource":  ToCamel(resourceName),
 			"field_name":          ToCamel(k),
 		}
-		fields += renderTemplate(tmpl, fieldContext)
+		fields += renderTemplate(fieldTmpl, fieldContext)
 	}
 	return fields
 }
 
+// Generate any imports needed by the go types of our columns, and the thumbnail methods of images, for the model
+func fieldImports() string {
+	for _, k := range sortedKeys(columns) {
+		if toGoType(columns[k]) == "[]string" || columns[k] == "image" {
+			return "\t\"strings\"\n"
+		}
+	}
+	return ""
+}
+
+// Generate any imports needed by the go types of our columns for the api, which has no thumbnail methods
+func apiImports() string {
+	for _, k := range sortedKeys(columns) {
+		if toGoType(columns[k]) == "[]string" {
+			return "\t\"strings\"\n"
+		}
+	}
+	return ""
+}
+
+// Return true if any columns are files uploaded with the form
+func hasUploads() bool {
+	for _, k := range sortedKeys(columns) {
+		if toInputType(columns[k]) == "file" {
+			return true
+		}
+	}
+	return false
+}
+
+// Generate golang to save uploaded files in the create and update actions, if the resource has any file columns
+func saveUploads() string {
+	if !hasUploads() {
+		return ""
+	}
+
+	return `
+	// Save any uploaded files, setting their public paths in values
+	err = saveUploads(context, values)
+	if err != nil {
+		return router.InternalError(err)
+	}
+`
+}
+
+// Generate golang to convert the comma separated lists posted by the form for array columns to array literals e.g. {a,b},
+// in the create and update actions, as the api does for arrays in requests
+func arrayParams() string {
+	tmpl := `	if v, ok := values["[[.col_name]]"]; ok {
+		values["[[.col_name]]"] = "{" + v + "}"
+	}
+`
+	params := ""
+	for _, k := range visibleColumns() {
+		if toGoType(columns[k]) != "[]string" {
+			continue
+		}
+		params += renderTemplate(tmpl, map[string]string{"col_name": k})
+	}
+	if params == "" {
+		return ""
+	}
+
+	return "\n\t// Convert the comma separated lists sent for array columns to array literals\n" + params
+}
+
+// Generate methods returning the thumbnail path of each image column
+func fileMethods() string {
+	tmpl := `
+// [[.field_name]]Thumbnail returns the public path of the thumbnail of [[.field_name]]
+func (m *[[.Fragmenta_Resource]]) [[.field_name]]Thumbnail() string {
+	return strings.TrimSuffix(m.[[.field_name]], ".jpg") + "-thumb.jpg"
+}
+`
+	methods := ""
+	for _, k := range sortedKeys(columns) {
+		if columns[k] != "image" {
+			continue
+		}
+
+		context := map[string]string{
+			"Fragmenta_Resource": ToCamel(resourceName),
+			"field_name":         ToCamel(k),
+		}
+		methods += renderTemplate(tmpl, context)
+	}
+	return methods
+}
+
+// Generate methods returning the values of each array column as a comma separated list, for forms and views
+func arrayMethods() string {
+	tmpl := `
+// [[.field_name]]Text returns [[.field_name]] as a comma separated list, as it is edited in forms
+func (m *[[.Fragmenta_Resource]]) [[.field_name]]Text() string {
+	return strings.Join(m.[[.field_name]], ",")
+}
+`
+	methods := ""
+	for _, k := range sortedKeys(columns) {
+		if toGoType(columns[k]) != "[]string" {
+			continue
+		}
+
+		context := map[string]string{
+			"Fragmenta_Resource": ToCamel(resourceName),
+			"field_name":         ToCamel(k),
+		}
+		methods += renderTemplate(tmpl, context)
+	}
+	return methods
+}
+
+// Generate constants and an options method for the values of each enum column
+func enums() string {
+	tmpl := `
+// [[.field_name]] values for [[.Fragmenta_Resource]].[[.field_name]]
+const (
+[[.constants]])
+
+// [[.field_name]]Values lists the allowed values of [[.field_name]]
+var [[.field_name]]Values = []string{[[.names]]}
+
+// [[.field_name]]Options returns the options for selecting [[.field_name]] in forms
+func (m *[[.Fragmenta_Resource]]) [[.field_name]]Options() []string {
+	return [[.field_name]]Values
+}
+`
+	output := ""
+	for _, k := range sortedKeys(columns) {
+		values := fieldEnumValues(columns[k])
+		if len(values) == 0 {
+			continue
+		}
+
+		constants := ""
+		var names []string
+		for _, v := range values {
+			name := enumConstantName(k, v)
+			constants += fmt.Sprintf("\t%s = %q\n", name, v)
+			names = append(names, name)
+		}
+
+		context := map[string]string{
+			"Fragmenta_Resource": ToCamel(resourceName),
+			"field_name":         ToCamel(k),
+			"constants":          constants,
+			"names":              strings.Join(names, ", "),
+		}
+		output += renderTemplate(tmpl, context)
+	}
+	return output
+}
+
+// Generate map entries from each enum column to its allowed values
+func enumValues() string {
+	values := ""
+	for _, k := range sortedKeys(columns) {
+		if len(fieldEnumValues(columns[k])) > 0 {
+			values += fmt.Sprintf("\t%q: %sValues,\n", k, ToCamel(k))
+		}
+	}
+	return values
+}
+
 // Generate a columns list
 func showcolumns() string {
 	tmpl := "\"[[.col_name]]\","
 	cols := ""
 
-	for _, k := range sortedKeys(columns) {
+	for _, k := range visibleColumns() {
 
 		context := map[string]string{
 			"col_name": k,
@@ -451,17 +959,265 @@ func showcolumns() string {
 	return cols
 }
 
+// Generate golang struct fields with json tags for our columns (for api responses)
+func jsonFields() string {
+	tmpl := "\t[[.field_name]]\t[[.field_type]]\t`json:\"[[.col_name]]\"`\n"
+	fields := ""
+	for _, k := range visibleColumns() {
+		fieldContext := map[string]string{
+			"col_name":   k,
+			"field_name": ToCamel(k),
+			"field_type": toGoType(columns[k]),
+		}
+
+		fields += renderTemplate(tmpl, fieldContext)
+	}
+	return fields
+}
+
+// Generate assignments of model fields for an api response struct literal
+// Name: page.Name,
+func responseFields() string {
+	tmpl := "\t\t[[.field_name]]:\t[[.fragmenta_resource]].[[.field_name]],\n"
+	fields := ""
+	for _, k := range visibleColumns() {
+		fieldContext := map[string]string{
+			"fragmenta_resource": resourceName,
+			"field_name":         ToCamel(k),
+		}
+
+		fields += renderTemplate(tmpl, fieldContext)
+	}
+	return fields
+}
+
+// Generate golang struct fields for an api request - fields are pointers so that we can tell if they were sent
+func requestFields() string {
+	tmpl := "\t[[.field_name]]\t*[[.field_type]]\t`json:\"[[.col_name]],omitempty\"`\n"
+	fields := ""
+	for _, k := range requestColumns() {
+		fieldContext := map[string]string{
+			"col_name":   k,
+			"field_name": ToCamel(k),
+			"field_type": toGoType(columns[k]),
+		}
+
+		fields += renderTemplate(tmpl, fieldContext)
+	}
+	return fields
+}
+
+// Generate golang to convert api request fields to model params, recording validation errors for missing fields
+func requestParams() string {
+	tmpl := `	if r.[[.field_name]] != nil {
+		params["[[.col_name]]"] = [[.value]]
+	} else if required {
+		errs = append(errs, ValidationError{Field: "[[.col_name]]", Message: "is required"})
+	}
+`
+	fields := ""
+	for _, k := range requestColumns() {
+		value := fmt.Sprintf("fmt.Sprint(*r.%s)", ToCamel(k))
+		switch toGoType(columns[k]) {
+		case "time.Time":
+			value = fmt.Sprintf("r.%s.UTC().Format(time.RFC3339)", ToCamel(k))
+		case "[]string":
+			value = fmt.Sprintf("\"{\" + strings.Join(*r.%s, \",\") + \"}\"", ToCamel(k))
+		}
+
+		fieldContext := map[string]string{
+			"col_name":   k,
+			"field_name": ToCamel(k),
+			"value":      value,
+		}
+
+		fields += renderTemplate(tmpl, fieldContext)
+	}
+	return fields
+}
+
+// Generate a relative path from the resource package to the project root (e.g. ../..), used by tests to find config
+func rootPathFromResource() string {
+	depth := len(strings.Split(path.Join(appGeneratePath(), ToPlural(resourceName)), "/"))
+	return strings.TrimSuffix(strings.Repeat("../", depth), "/")
+}
+
+// Generate map entries with valid params for our columns, for use in tests
+// n is used to vary the values, so that updates can use different values from creates
+// Params posted with the form give arrays as a comma separated list, rather than the array literal used by the model
+func testParams(n int, form bool) string {
+	tmpl := "\t\"[[.col_name]]\": [[.value]],\n"
+	fields := ""
+	for _, k := range visibleColumns() {
+		value := testValue(k, columns[k], n)
+		if form && toGoType(columns[k]) == "[]string" {
+			value = strings.Trim(value, "{}")
+		}
+
+		fieldContext := map[string]string{
+			"col_name": k,
+			"value":    fmt.Sprintf("%q", value),
+		}
+
+		fields += renderTemplate(tmpl, fieldContext)
+	}
+	return fields
+}
+
+// Return a valid value for a column of the given type, varied by n
+func testValue(col string, fieldType string, n int) string {
+	values := fieldEnumValues(fieldType)
+	if len(values) > 0 {
+		return values[(n-1)%len(values)]
+	}
+
+	switch toSQLType(fieldType) {
+	case "json", "jsonb":
+		return fmt.Sprintf(`{"n": %d}`, n)
+	case "uuid":
+		return fmt.Sprintf("00000000-0000-0000-0000-%012d", n)
+	case "date":
+		return fmt.Sprintf("2020-01-%02d", n)
+	}
+
+	switch toGoType(fieldType) {
+	case "int64":
+		return fmt.Sprintf("%d", n)
+	case "float64":
+		return fmt.Sprintf("%d.5", n)
+	case "time.Time":
+		return fmt.Sprintf("2020-01-%02d 12:00:00", n)
+	case "bool":
+		return fmt.Sprintf("%t", n%2 == 1)
+	case "[]string":
+		return fmt.Sprintf("{%s %d}", ToCamel(col), n)
+	default:
+		return fmt.Sprintf("%s %d", ToCamel(col), n)
+	}
+}
+
+// Return an invalid value for a column of the given type, or an empty string if any value is valid
+func testInvalidValue(fieldType string) string {
+	if len(fieldEnumValues(fieldType)) > 0 {
+		return "not an option"
+	}
+
+	switch toGoType(fieldType) {
+	case "int64", "float64":
+		return "not a number"
+	case "time.Time":
+		return "not a time"
+	}
+	return ""
+}
+
+// Generate test cases for creating the model with an invalid value in each column which can be invalid
+func invalidModelTests() string {
+	tmpl := "\t{\"invalid [[.col_name]]\", withParam(\"[[.col_name]]\", \"[[.value]]\"), false},\n"
+	tests := ""
+	for _, k := range visibleColumns() {
+		value := testInvalidValue(columns[k])
+		if value == "" {
+			continue
+		}
+
+		context := map[string]string{
+			"col_name": k,
+			"value":    value,
+		}
+		tests += renderTemplate(tmpl, context)
+	}
+	return tests
+}
+
+// Generate test cases for each route added by generate resource, with valid and invalid params for create and update
+func routeTests() string {
+	src := "package routes\nfunc setupRoutes(r *router.Router) {\n" + renderTemplate(resourceRoutesTemplate, map[string]interface{}{
+		"fragmenta_resources":   ToPlural(resourceName),
+		"fragmenta_resource":    resourceName,
+		"fragmenta_route_path":  resourceRoutePath(),
+		"fragmenta_id_pattern":  idPattern(resourceName),
+		"fragmenta_soft_delete": generateFlag("soft-delete"),
+	}) + "\n}\n"
+
+	routes, err := parseRoutes([]byte(src))
+	if err != nil {
+		log.Printf("Error parsing resource routes %s", err)
+		return ""
+	}
+
+	tmpl := "\t{\"[[.method]]\", \"[[.path]]\", [[.params]], [[.valid]]},\n"
+	tests := ""
+	for _, r := range routes {
+		context := map[string]string{
+			"method": r.Method,
+			"path":   routeParamRegexp.ReplaceAllString(r.Pattern, "{$1}"),
+			"params": "nil",
+			"valid":  "true",
+		}
+
+		// Tests are run without a logged in user, so if the policy requires a role every request should be refused
+		if authRole() != "" {
+			if r.Handler == "HandleCreate" || r.Handler == "HandleUpdate" {
+				context["params"] = "testParams"
+			}
+			context["valid"] = "false"
+			tests += renderTemplate(tmpl, context)
+			continue
+		}
+
+		if r.Handler == "HandleCreate" || r.Handler == "HandleUpdate" {
+			context["params"] = "testParams"
+			tests += renderTemplate(tmpl, context)
+
+			// Add a test for each column which can be invalid, except the parent id which is set from the route
+			for _, k := range visibleColumns() {
+				value := testInvalidValue(columns[k])
+				if value == "" || (resourceParent != "" && k == resourceParent+"_id") {
+					continue
+				}
+				context["params"] = fmt.Sprintf("withParam(%q, %q)", k, value)
+				context["valid"] = "false"
+				tests += renderTemplate(tmpl, context)
+			}
+			continue
+		}
+
+		tests += renderTemplate(tmpl, context)
+
+		// Add a test for a sorted page of the index, and a search if the resource has searchable columns
+		if r.Handler == "HandleIndex" {
+			context["path"] += "?sort=-created_at&page=2&per_page=10"
+			if len(searchableColumns()) > 0 {
+				context["path"] += "&q=test"
+			}
+			tests += renderTemplate(tmpl, context)
+		}
+	}
+	return tests
+}
+
 // Generate form fields for our columns
 func formFields() string {
 
 	fields := ""
 	tmpl := `    {{ [[.method]] "[[.field_name]]" "[[.column_name]]" .[[.fragmenta_resource]].[[.field_name]] }}
 `
-	for _, k := range sortedKeys(columns) {
+	for _, k := range visibleColumns() {
 
-		// We add status as a special case menu
-		if k == "status" {
-			fields += fmt.Sprintf(`{{ select "Status" "status" .%s.Status .%s.StatusOptions }}`, resourceName, resourceName)
+		// The parent id of nested resources is set from the route
+		if resourceParent != "" && k == resourceParent+"_id" {
+			continue
+		}
+
+		// Enums are shown as a select of their values
+		if len(fieldEnumValues(columns[k])) > 0 {
+			fields += fmt.Sprintf("    {{ selectarray %q %q .%s.%s .%s.%sOptions }}\n", ToCamel(k), k, resourceName, ToCamel(k), resourceName, ToCamel(k))
+		} else if toGoType(columns[k]) == "[]string" {
+			// Arrays are edited as a comma separated list, which the actions convert to an array literal
+			fields += fmt.Sprintf("    {{ field %q %q .%s.%sText }}\n", ToCamel(k), k, resourceName, ToCamel(k))
+		} else if toInputType(columns[k]) == "file" {
+			fields += fmt.Sprintf("    <div class=\"field\">\n      <label>%s</label>\n      <input type=\"file\" name=\"%s\">\n    </div>\n", ToCamel(k), k)
 		} else {
 			fieldContext := map[string]string{
 				"fragmenta_resources": ToPlural(resourceName),
@@ -479,109 +1235,186 @@ func formFields() string {
 		}
 
 	}
+
+	// Users of auth set their password with the form, which is only stored as a hash, so is never filled in
+	if authResource {
+		fields += passwordFormFields
+	}
 	return fields
 }
 
+// The password fields added to the form of the users resource by generate auth, see passwordParam in the actions
+const passwordFormFields = `    <div class="field">
+      <label>Password</label>
+      <input type="password" name="password" autocomplete="new-password">
+    </div>
+    <div class="field">
+      <label>Confirm password</label>
+      <input type="password" name="password_confirmation" autocomplete="new-password">
+    </div>
+`
+
+// visibleColumns returns the column names sorted, without any hidden columns
+func visibleColumns() []string {
+	var cols []string
+	for _, k := range sortedKeys(columns) {
+		if !contains(k, hiddenColumns) {
+			cols = append(cols, k)
+		}
+	}
+	return cols
+}
+
+// requestColumns returns the visible columns which may be sent in api requests, which do not include
+// the paths of file and image columns, as these are set by uploads with the form
+func requestColumns() []string {
+	var cols []string
+	for _, k := range visibleColumns() {
+		if toInputType(columns[k]) != "file" {
+			cols = append(cols, k)
+		}
+	}
+	return cols
+}
+
+// authRole returns the role required by the generated policy for the resource actions, set with --auth role,
+// or an empty string if the actions are open to all
+func authRole() string {
+	role := generateFlagValue("auth")
+	if role == "true" {
+		return "admin"
+	}
+	return strings.ToLower(role)
+}
+
+// resourceTableName returns the database table for the resource, which is the plural of the name unless set
+func resourceTableName() string {
+	if resourceTable != "" {
+		return resourceTable
+	}
+	return ToPlural(resourceName)
+}
+
 // Make this file name concrete by substituting values
 func reifyName(name string) string {
 	name = strings.Replace(name, ".go.tmpl", ".go", -1)   // go files
 	name = strings.Replace(name, ".got.tmpl", ".got", -1) // template files
-	name = strings.Replace(name, "fragmenta_resource", resourceName, -1)
+	// Replace the plural before the singular, which it contains
 	name = strings.Replace(name, "fragmenta_resources", ToPlural(resourceName), -1)
+	name = strings.Replace(name, "fragmenta_resource", resourceName, -1)
 	return name
 }
 
 // Make this template string concrete by filling in values
+// As well as the pre-rendered fragmenta_ snippets below, templates can use the structured values
+// in templateContext to generate anything they need
 func reifyString(tmpl string) string {
-	context := map[string]string{
-		"fragmenta_app_path":    path.Join(appPath(), appGeneratePath()),
-		"fragmenta_resources":   ToPlural(resourceName),
-		"fragmenta_resource":    resourceName,
-		"Fragmenta_Resources":   ToCamel(ToPlural(resourceName)),
-		"Fragmenta_Resource":    ToCamel(resourceName),
-		"fragmenta_fields":      structFields(),
-		"fragmenta_form_fields": formFields(),
-		"fragmenta_show_fields": showFields(),
-		"fragmenta_new_fields":  newFields(),
-		"fragmenta_columns":     showcolumns(),
-		"fragmenta_db":          ConfigDevelopment["db"],
-		"fragmenta_db_user":     ConfigDevelopment["db_user"],
-		"fragmenta_app_name":    appServerName(),
+	context := map[string]interface{}{
+		"fragmenta_app_path":          path.Join(appPath(), appGeneratePath()),
+		"fragmenta_resources":         ToPlural(resourceName),
+		"fragmenta_resource":          resourceName,
+		"fragmenta_table":             resourceTableName(),
+		"Fragmenta_Resources":         ToCamel(ToPlural(resourceName)),
+		"Fragmenta_Resource":          ToCamel(resourceName),
+		"fragmenta_fields":            structFields(),
+		"fragmenta_form_fields":       formFields(),
+		"fragmenta_show_fields":       showFields(),
+		"fragmenta_new_fields":        newFields(),
+		"fragmenta_columns":           showcolumns(),
+		"fragmenta_imports":           fieldImports(),
+		"fragmenta_api_imports":       apiImports(),
+		"fragmenta_enums":             enums(),
+		"fragmenta_enum_values":       enumValues(),
+		"fragmenta_file_methods":      fileMethods(),
+		"fragmenta_array_methods":     arrayMethods(),
+		"fragmenta_search":            searchFunc(),
+		"fragmenta_searchable":        len(searchableColumns()) > 0,
+		"fragmenta_save_uploads":      saveUploads(),
+		"fragmenta_array_params":      arrayParams(),
+		"fragmenta_has_uploads":       hasUploads(),
+		"fragmenta_has_auth":          hasAuth(),
+		"fragmenta_auth_resource":     authResource,
+		"fragmenta_auth_role":         authRole(),
+		"fragmenta_soft_delete":       generateFlag("soft-delete"),
+		"fragmenta_audit":             generateFlag("audit"),
+		"fragmenta_uuid":              uuidKeys(),
+		"fragmenta_primary_key":       primaryKeySQL(),
+		"fragmenta_id_type":           idGoType(resourceName),
+		"fragmenta_id_pattern":        idPattern(resourceName),
+		"fragmenta_id_param":          idParam(resourceName, "id"),
+		"fragmenta_parent_id_param":   idParam(resourceParent, resourceParent+"_id"),
+		"fragmenta_route_path":        resourceRoutePath(),
+		"fragmenta_url":               resourceURL(true),
+		"fragmenta_index_url":         resourceURL(false),
+		"fragmenta_breadcrumbs":       breadcrumbs(true),
+		"fragmenta_index_breadcrumbs": breadcrumbs(false),
+		"fragmenta_json_fields":       jsonFields(),
+		"fragmenta_response_fields":   responseFields(),
+		"fragmenta_request_fields":    requestFields(),
+		"fragmenta_request_params":    requestParams(),
+		"fragmenta_root_path":         rootPathFromResource(),
+		"fragmenta_test_package":      testPackage(),
+		"fragmenta_test_root_path":    testRootPath(),
+		"fragmenta_test_params":       testParams(1, false),
+		"fragmenta_update_params":     testParams(2, false),
+		"fragmenta_form_test_params":  testParams(1, true),
+		"fragmenta_invalid_tests":     invalidModelTests(),
+		"fragmenta_route_tests":       routeTests(),
+		"fragmenta_db":                ConfigDevelopment["db"],
+		"fragmenta_db_user":           ConfigDevelopment["db_user"],
+		"fragmenta_app_name":          appServerName(),
+	}
+
+	context["fragmenta_routes"] = renderTemplate(resourceRoutesTemplate, context)
+
+	for k, v := range templateContext() {
+		context[k] = v
 	}
 
 	return renderTemplate(tmpl, context)
 }
 
-// Convert a user-defined type to a go type
-func toValidateType(fieldType string) string {
-
-	switch fieldType {
-	case "text", "string", "char(255)":
-		return "String"
-	case "int", "integer", "bigint":
-		return "Int"
-	case "time", "datetime", "timestamp", "date":
-		return "Time"
-	case "float":
-		return "Float"
-	case "double":
-		return "Float"
-	}
-
-	return fieldType
-}
-
-// Convert a user-defined type to a go type
-func toGoType(fieldType string) string {
-
-	switch fieldType {
-	case "text", "string", "char(255)":
-		return "string"
-	case "int", "integer", "bigint":
-		return "int64"
-	case "time", "datetime", "timestamp", "date":
-		return "time.Time"
-	case "float":
-		return "float"
-	case "double":
-		return "float64"
-	}
-
-	return fieldType
-}
-
-// Convert a user-defined type to an sql type
-// this may vary with the database
-func toSQLType(fieldType string) string {
-	switch fieldType {
-	case "text", "string", "char(255)":
-		return "text"
-	case "int", "int64", "integer", "bigint":
-		return "integer"
-	case "timestamp", "time", "datetime", "date":
-		return "timestamp"
-	case "float":
-		return "real"
-	case "double":
-		return "double precision"
-	default:
-		return fieldType
+// parseGenerateFlags removes any --flags from args, storing them in generateFlags, and returns the remaining args
+// Flags may be given as --name or --name=value
+func parseGenerateFlags(args []string) []string {
+	generateFlags = make(map[string]string, 0)
+	var remaining []string
+
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if !strings.HasPrefix(a, "--") {
+			remaining = append(remaining, a)
+			continue
+		}
+
+		parts := strings.SplitN(strings.TrimPrefix(a, "--"), "=", 2)
+		if len(parts) == 2 {
+			generateFlags[parts[0]] = parts[1]
+		} else if contains(parts[0], generateValueFlags) && i+1 < len(args) && isFlagValue(args[i+1]) {
+			generateFlags[parts[0]] = args[i+1]
+			i++
+		} else {
+			generateFlags[parts[0]] = "true"
+		}
 	}
 
+	return remaining
 }
 
-// Convert a user-defined type to an input type
-func toInputType(fieldType string) string {
-	switch fieldType {
-	case "text", "string", "char(255)":
-		return "textfield"
-	case "int", "int64", "integer", "bigint", "float", "double":
-		return "number"
-	case "timestamp", "time", "datetime", "date":
-		return "date"
-	default:
-		return fieldType
-	}
+// isFlagValue returns true if arg may be the value of the flag before it, rather than a flag or field
+func isFlagValue(arg string) bool {
+	return !strings.HasPrefix(arg, "--") && !strings.Contains(arg, ":")
+}
+
+// generateFlag returns true if the named flag was passed to generate
+func generateFlag(name string) bool {
+	_, ok := generateFlags[name]
+	return ok
+}
+
+// generateFlagValue returns the value of the named flag passed to generate, or an empty string
+func generateFlagValue(name string) string {
+	return generateFlags[name]
 }
 
 // sortedKeys returns the string keys of a map[string] sorted
@@ -612,13 +1445,15 @@ func generateMigration(name string, content string) {
 
 	fmt.Println("Generating migration: ", name)
 
-	err := ioutil.WriteFile(path, []byte(content), 0744)
+	written, err := writeGeneratedFile(path, []byte(content))
 	if err != nil {
 		fmt.Println("Error writing migration file: ", path)
 		return
 	}
 
-	fmt.Println("Generated migration at: ", path)
+	if written {
+		fmt.Println("Generated migration at: ", path)
+	}
 
 }
 
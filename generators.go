@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+)
+
+// The manifest file which marks a directory in src/lib/templates as a named generator
+const generatorManifest = "generator.json"
+
+// generatorArgs holds the arguments passed to a named generator, for use in templates as .Args
+var generatorArgs map[string]string
+
+// generator describes a named generator defined by a project in src/lib/templates/<generator>/generator.json
+type generator struct {
+	// Description is shown when listing generators
+	Description string `json:"description"`
+
+	// Path is the destination for generated files relative to the project, and may use template values
+	// it defaults to src/[[.fragmenta_resources]]
+	Path string `json:"path"`
+
+	// Arguments lists the arguments expected after the name, in order
+	Arguments []generatorArgument `json:"arguments"`
+}
+
+// generatorArgument describes one argument to a named generator
+type generatorArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Default     string `json:"default"`
+}
+
+// readGenerator reads the manifest for the named generator in the app, and returns nil if there is none
+func readGenerator(name string) (*generator, error) {
+	manifestPath := path.Join(appTemplateSetPath(name), generatorManifest)
+	data, err := ioutil.ReadFile(manifestPath)
+	if err != nil {
+		return nil, nil
+	}
+
+	g := &generator{}
+	err = json.Unmarshal(data, g)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s :%s", manifestPath, err)
+	}
+
+	return g, nil
+}
+
+// appGenerators returns the names of the named generators defined in the app
+func appGenerators() []string {
+	var names []string
+
+	entries, err := ioutil.ReadDir(appTemplateSetPath(""))
+	if err != nil {
+		return names
+	}
+	for _, e := range entries {
+		if e.IsDir() && fileExists(path.Join(appTemplateSetPath(e.Name()), generatorManifest)) {
+			names = append(names, e.Name())
+		}
+	}
+
+	return names
+}
+
+// generateNamed runs the named generator from the app templates with args, returning false if there is no such generator
+// Expects:
+// - generate mailer welcome [arguments from manifest]*
+func generateNamed(name string, args []string) bool {
+	g, err := readGenerator(name)
+	if err != nil {
+		fmt.Println(err)
+		return true
+	}
+	if g == nil {
+		return false
+	}
+
+	resourceName = strings.ToLower(args[0])
+	columns = make(map[string]string, 0)
+	columnModifiers = make(map[string][]string, 0)
+	args = args[1:]
+
+	// Map the args onto the arguments in the manifest, falling back to defaults
+	generatorArgs = make(map[string]string, 0)
+	for i, a := range g.Arguments {
+		if i < len(args) {
+			generatorArgs[a.Name] = args[i]
+		} else if a.Default != "" {
+			generatorArgs[a.Name] = a.Default
+		} else {
+			fmt.Printf("Missing argument %s for generator %s\n%s", a.Name, name, generatorUsage(name, g))
+			return true
+		}
+	}
+
+	dstPath := g.Path
+	if len(dstPath) == 0 {
+		dstPath = path.Join(appGeneratePath(), "[[.fragmenta_resources]]")
+	}
+	dstPath = path.Join(fullAppPath(), reifyString(dstPath))
+
+	fmt.Printf("Generating %s %s with %v\n", name, resourceName, generatorArgs)
+	fmt.Printf("Creating files at %s\n", dstPath)
+	copyAndReifyFiles(os.DirFS(appTemplateSetPath(name)), dstPath)
+
+	return true
+}
+
+// generatorUsage returns usage instructions for a named generator
+func generatorUsage(name string, g *generator) string {
+	usage := fmt.Sprintf("  fragmenta generate %s [name]", name)
+	for _, a := range g.Arguments {
+		usage += fmt.Sprintf(" [%s]", a.Name)
+	}
+	usage += " -> " + g.Description + "\n"
+	for _, a := range g.Arguments {
+		usage += fmt.Sprintf("    %s - %s", a.Name, a.Description)
+		if a.Default != "" {
+			usage += fmt.Sprintf(" (default %s)", a.Default)
+		}
+		usage += "\n"
+	}
+	return usage
+}
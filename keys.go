@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"path"
+)
+
+// The route pattern matched by integer ids
+const intIDPattern = "[0-9]+"
+
+// The route pattern matched by uuid ids, in the 8-4-4-4-12 form of hex digits
+const uuidIDPattern = "[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}"
+
+// uuidKeys returns true if the resource being generated has a uuid primary key, see resourceUUID
+func uuidKeys() bool {
+	return resourceUUID(resourceName)
+}
+
+// resourceUUID returns true if the named resource has a uuid primary key rather than a serial integer
+// The resource being generated uses uuids if given --uuid (or not if given --uuid=false),
+// otherwise an existing model is used to find the type of its id, and new resources use uuids if
+// primary_key is set to uuid in the config
+func resourceUUID(name string) bool {
+	if name == resourceName && generateFlag("uuid") {
+		return generateFlagValue("uuid") != "false"
+	}
+
+	_, fields := modelFields(path.Join(fullAppPath(), appGeneratePath(), ToPlural(name)))
+	if fields != nil {
+		return fields["id"] == "string"
+	}
+
+	return ConfigDevelopment["primary_key"] == "uuid"
+}
+
+// uuidExtensionSQL returns the sql to enable the pgcrypto extension, which provides gen_random_uuid before postgres 13,
+// if the resource being generated has a uuid primary key
+func uuidExtensionSQL() string {
+	if uuidKeys() {
+		return "CREATE EXTENSION IF NOT EXISTS pgcrypto;\n"
+	}
+	return ""
+}
+
+// primaryKeySQL returns the sql for the id column of the resource being generated
+func primaryKeySQL() string {
+	if uuidKeys() {
+		return "id uuid NOT NULL DEFAULT gen_random_uuid()"
+	}
+	return "id SERIAL NOT NULL"
+}
+
+// keyFieldType returns the field type for columns which hold the id of the named resource, e.g. the parent_id of nested resources
+func keyFieldType(name string) string {
+	if resourceUUID(name) {
+		return "uuid"
+	}
+	return "int"
+}
+
+// idGoType returns the go type of the ids of the named resource
+func idGoType(name string) string {
+	return toGoType(keyFieldType(name))
+}
+
+// idPattern returns the route pattern matched by ids of the named resource
+func idPattern(name string) string {
+	if resourceUUID(name) {
+		return uuidIDPattern
+	}
+	return intIDPattern
+}
+
+// idParam returns code to read the param key holding an id of the named resource from the router context
+func idParam(name string, key string) string {
+	if resourceUUID(name) {
+		return fmt.Sprintf("context.Param(%q)", key)
+	}
+	return fmt.Sprintf("context.ParamInt(%q)", key)
+}
@@ -126,14 +126,22 @@ func openDatabase(config map[string]string) error {
 
 // We should perhaps do this with the db driver instead
 func readMetadata() []string {
+	migrations, err := appliedMigrations()
+	if err != nil {
+		log.Printf("Error determining migration version. Perhaps the metadata table has not be created as yet.\n%s", err)
+	}
+	return migrations
+}
+
+// appliedMigrations returns the migrations recorded in the metadata table, or an error if they cannot be read
+func appliedMigrations() ([]string, error) {
 	var migrations []string
 
 	sql := "select migration_version from fragmenta_metadata order by id desc;"
 
 	rows, err := query.QuerySQL(sql)
 	if err != nil {
-		log.Printf("Error determining migration version. Perhaps the metadata table has not be created as yet.\n%s", err)
-		return migrations
+		return migrations, err
 	}
 
 	// We expect just one row, with one column (count)
@@ -142,14 +150,13 @@ func readMetadata() []string {
 		var migration string
 		err := rows.Scan(&migration)
 		if err != nil {
-			log.Printf("Database ERROR %s", err)
-			return migrations
+			return migrations, err
 		}
 		migrations = append(migrations, migration)
 
 	}
 
-	return migrations
+	return migrations, rows.Err()
 }
 
 // Update the database with row(s) recording what we have done
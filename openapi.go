@@ -0,0 +1,361 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The file the openapi spec is written to
+const openAPIPath = "public/openapi.json"
+
+// openAPIObject is used to build the nested objects of an openapi document
+type openAPIObject map[string]interface{}
+
+// generateOpenAPI writes an OpenAPI 3 document describing the routes in routes.go and the models they use
+func generateOpenAPI() {
+	routesPath := appRoutesFilePath()
+	data, err := ioutil.ReadFile(routesPath)
+	if err != nil {
+		fmt.Printf("#error Error reading routes at:%s :%s", routesPath, err)
+		return
+	}
+
+	routes, err := parseRoutes(data)
+	if err != nil {
+		fmt.Printf("#error Error parsing routes at:%s :%s", routesPath, err)
+		return
+	}
+
+	fmt.Println("Generating openapi spec at: ", openAPIPath)
+
+	paths := openAPIObject{}
+	schemas := openAPIObject{}
+
+	for _, r := range routes {
+		pattern, params := openAPIPattern(r.Pattern)
+
+		operation := openAPIObject{
+			"operationId": fmt.Sprintf("%s.%s", r.PackageName, r.Handler),
+			"summary":     fmt.Sprintf("%s %s", r.Handler, r.Pattern),
+			"responses":   openAPIObject{"200": openAPIObject{"description": "OK"}},
+		}
+		if len(params) > 0 {
+			operation["parameters"] = params
+		}
+
+		// Describe requests and responses using the model for routes within a resource package
+		schema := openAPIModelSchema(r.Package, schemas)
+		if schema != "" {
+			operation["tags"] = []string{schema}
+			if path.Base(r.Package) == "api" {
+				openAPIJSONOperation(operation, r.Handler, schema)
+			} else {
+				openAPIHTMLOperation(operation, r.Method, r.Handler, schema)
+			}
+		}
+
+		item, ok := paths[pattern].(openAPIObject)
+		if !ok {
+			item = openAPIObject{}
+			paths[pattern] = item
+		}
+		item[strings.ToLower(r.Method)] = operation
+	}
+
+	spec := openAPIObject{
+		"openapi": "3.0.3",
+		"info": openAPIObject{
+			"title":   appServerName(),
+			"version": "1.0.0",
+		},
+		"paths":      paths,
+		"components": openAPIObject{"schemas": schemas},
+	}
+
+	output, err := json.MarshalIndent(spec, "", "  ")
+	if err != nil {
+		fmt.Printf("Error generating openapi spec :%s\n", err)
+		return
+	}
+
+	written, err := overwriteGeneratedFile(openAPIPath, append(output, '\n'))
+	if err != nil {
+		fmt.Println("Error writing openapi spec: ", openAPIPath)
+		return
+	}
+
+	if written {
+		fmt.Println("Generated openapi spec")
+	}
+}
+
+// openAPIPattern converts a route pattern to an openapi path, and returns the path parameters it contains
+func openAPIPattern(pattern string) (string, []openAPIObject) {
+	var params []openAPIObject
+
+	for _, m := range routeParamRegexp.FindAllStringSubmatch(pattern, -1) {
+		schema := openAPIObject{"type": "string"}
+		if m[2] == intIDPattern {
+			schema = openAPIObject{"type": "integer", "format": "int64"}
+		} else if m[2] == uuidIDPattern {
+			schema = openAPIObject{"type": "string", "format": "uuid"}
+		} else if m[2] != "" {
+			schema["pattern"] = "^" + m[2] + "$"
+		}
+
+		params = append(params, openAPIObject{
+			"name":     m[1],
+			"in":       "path",
+			"required": true,
+			"schema":   schema,
+		})
+	}
+
+	return routeParamRegexp.ReplaceAllString(pattern, "{$1}"), params
+}
+
+// openAPIJSONOperation describes the JSON request and response of an api handler generated by generate api
+func openAPIJSONOperation(operation openAPIObject, handler string, schema string) {
+	ref := openAPIObject{"$ref": "#/components/schemas/" + schema}
+	request := openAPIObject{"$ref": "#/components/schemas/" + schema + "Request"}
+	errors := openAPIObject{"description": "Validation failed", "content": openAPIJSON(openAPIObject{"$ref": "#/components/schemas/ErrorResponse"})}
+
+	switch handler {
+	case "HandleIndex":
+		operation["parameters"] = []openAPIObject{
+			{"name": "page", "in": "query", "schema": openAPIObject{"type": "integer"}},
+			{"name": "per_page", "in": "query", "schema": openAPIObject{"type": "integer"}},
+		}
+		operation["responses"] = openAPIObject{"200": openAPIObject{"description": "OK", "content": openAPIJSON(openAPIObject{
+			"type": "object",
+			"properties": openAPIObject{
+				"data":     openAPIObject{"type": "array", "items": ref},
+				"page":     openAPIObject{"type": "integer"},
+				"per_page": openAPIObject{"type": "integer"},
+				"total":    openAPIObject{"type": "integer", "format": "int64"},
+			},
+		})}}
+	case "HandleShow":
+		operation["responses"] = openAPIObject{"200": openAPIObject{"description": "OK", "content": openAPIJSON(ref)}, "404": openAPIObject{"description": "Not found"}}
+	case "HandleCreate":
+		operation["requestBody"] = openAPIObject{"required": true, "content": openAPIJSON(request)}
+		operation["responses"] = openAPIObject{"201": openAPIObject{"description": "Created", "content": openAPIJSON(ref)}, "422": errors}
+	case "HandleUpdate":
+		operation["requestBody"] = openAPIObject{"required": true, "content": openAPIJSON(request)}
+		operation["responses"] = openAPIObject{"200": openAPIObject{"description": "OK", "content": openAPIJSON(ref)}, "404": openAPIObject{"description": "Not found"}, "422": errors}
+	case "HandleDestroy":
+		operation["responses"] = openAPIObject{"204": openAPIObject{"description": "No content"}, "404": openAPIObject{"description": "Not found"}}
+	}
+}
+
+// openAPIHTMLOperation describes the form request and html response of an action generated by generate resource
+func openAPIHTMLOperation(operation openAPIObject, method string, handler string, schema string) {
+	if handler == "HandleIndex" {
+		params, _ := operation["parameters"].([]openAPIObject)
+		operation["parameters"] = append(params,
+			openAPIObject{"name": "page", "in": "query", "schema": openAPIObject{"type": "integer"}},
+			openAPIObject{"name": "per_page", "in": "query", "schema": openAPIObject{"type": "integer"}},
+			openAPIObject{"name": "sort", "in": "query", "description": "a column to sort by, prefixed with - to sort descending", "schema": openAPIObject{"type": "string"}},
+		)
+	}
+	if method == "POST" {
+		operation["requestBody"] = openAPIObject{"content": openAPIObject{
+			"application/x-www-form-urlencoded": openAPIObject{"schema": openAPIObject{"$ref": "#/components/schemas/" + schema + "Request"}},
+		}}
+	}
+	operation["responses"] = openAPIObject{"200": openAPIObject{"description": "OK", "content": openAPIObject{"text/html": openAPIObject{}}}}
+}
+
+// openAPIJSON returns an openapi content object for JSON with the given schema
+func openAPIJSON(schema openAPIObject) openAPIObject {
+	return openAPIObject{"application/json": openAPIObject{"schema": schema}}
+}
+
+// openAPIModelSchema adds schemas to schemas for the model in the resource package containing the handler package pkg,
+// and returns the model schema name, or an empty string if there is no model
+func openAPIModelSchema(pkg string, schemas openAPIObject) string {
+	if !strings.HasPrefix(pkg, appPath()+"/") {
+		return ""
+	}
+
+	// Handlers are in a package within the resource package
+	resourcePath := path.Join(fullAppPath(), strings.TrimPrefix(path.Dir(pkg), appPath()))
+	name, fields := modelFields(resourcePath)
+	if name == "" {
+		return ""
+	}
+
+	if _, ok := schemas[name]; ok {
+		return name
+	}
+
+	properties := openAPIObject{
+		"id":         openAPIObject{"type": "integer", "format": "int64"},
+		"created_at": openAPIObject{"type": "string", "format": "date-time"},
+		"updated_at": openAPIObject{"type": "string", "format": "date-time"},
+	}
+	// Models with uuid keys have a string id field, which is not sent in requests
+	if fields["id"] == "string" {
+		properties["id"] = openAPIObject{"type": "string", "format": "uuid"}
+		delete(fields, "id")
+	}
+	for _, k := range sortedKeys(fields) {
+		properties[k] = openAPISchemaType(fields[k])
+	}
+
+	// Requests may only set the params allowed by the model, which do not include columns set by code
+	// like deleted_at, created_by and updated_by, and the api requires all of the fields of its request
+	requestProperties := openAPIObject{}
+	for _, k := range modelAllowedParams(resourcePath) {
+		if t, ok := fields[k]; ok {
+			requestProperties[k] = openAPISchemaType(t)
+		}
+	}
+	request := openAPIObject{"type": "object", "properties": requestProperties}
+	required := apiRequestFields(path.Join(resourcePath, "api"), name+"Request")
+	if len(required) > 0 {
+		request["required"] = required
+	}
+
+	schemas[name] = openAPIObject{"type": "object", "properties": properties}
+	schemas[name+"Request"] = request
+	schemas["ErrorResponse"] = openAPIObject{
+		"type": "object",
+		"properties": openAPIObject{
+			"error": openAPIObject{"type": "string"},
+			"errors": openAPIObject{"type": "array", "items": openAPIObject{
+				"type": "object",
+				"properties": openAPIObject{
+					"field":   openAPIObject{"type": "string"},
+					"message": openAPIObject{"type": "string"},
+				},
+			}},
+		},
+	}
+
+	return name
+}
+
+// modelFields parses the go files in the resource package at resourcePath, and returns the name of the model struct
+// (the struct which embeds model.Model), and a map of its column names to go types
+func modelFields(resourcePath string) (string, map[string]string) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, resourcePath, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return "", nil
+	}
+
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			for _, d := range file.Decls {
+				g, ok := d.(*ast.GenDecl)
+				if !ok || g.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range g.Specs {
+					t := spec.(*ast.TypeSpec)
+					s, ok := t.Type.(*ast.StructType)
+					if !ok || !embedsModel(s) {
+						continue
+					}
+
+					fields := map[string]string{}
+					for _, f := range s.Fields.List {
+						for _, n := range f.Names {
+							if n.IsExported() {
+								fields[ToSnake(n.Name)] = exprString(fset, f.Type)
+							}
+						}
+					}
+					return t.Name.Name, fields
+				}
+			}
+		}
+	}
+
+	return "", nil
+}
+
+// apiRequestFields parses the go files in the api package at apiPath, and returns the json names of the fields
+// of the request struct named name, which are required when creating a resource with the api
+func apiRequestFields(apiPath string, name string) []string {
+	var fields []string
+
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, apiPath, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return fields
+	}
+
+	for _, pkg := range pkgs {
+		ast.Inspect(pkg, func(n ast.Node) bool {
+			t, ok := n.(*ast.TypeSpec)
+			if !ok || t.Name.Name != name {
+				return true
+			}
+			s, ok := t.Type.(*ast.StructType)
+			if !ok {
+				return false
+			}
+			for _, f := range s.Fields.List {
+				if f.Tag == nil {
+					continue
+				}
+				tag, _ := strconv.Unquote(f.Tag.Value)
+				key := strings.Split(reflect.StructTag(tag).Get("json"), ",")[0]
+				if key != "" && key != "-" {
+					fields = append(fields, key)
+				}
+			}
+			return false
+		})
+	}
+
+	sort.Strings(fields)
+	return fields
+}
+
+// embedsModel returns true if the struct embeds model.Model
+func embedsModel(s *ast.StructType) bool {
+	for _, f := range s.Fields.List {
+		sel, ok := f.Type.(*ast.SelectorExpr)
+		if len(f.Names) == 0 && ok && sel.Sel.Name == "Model" {
+			return true
+		}
+	}
+	return false
+}
+
+// openAPISchemaType converts a go type to an openapi schema
+func openAPISchemaType(goType string) openAPIObject {
+	switch goType {
+	case "string":
+		return openAPIObject{"type": "string"}
+	case "int", "int64", "int32":
+		return openAPIObject{"type": "integer", "format": "int64"}
+	case "float64", "float32":
+		return openAPIObject{"type": "number"}
+	case "bool":
+		return openAPIObject{"type": "boolean"}
+	case "time.Time":
+		return openAPIObject{"type": "string", "format": "date-time"}
+	case "[]string":
+		return openAPIObject{"type": "array", "items": openAPIObject{"type": "string"}}
+	}
+
+	return openAPIObject{}
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// appRoute describes a route added in the routes.go file
+type appRoute struct {
+	Method      string
+	Pattern     string
+	Package     string
+	PackageName string
+	Handler     string
+	Line        int
+}
+
+// Methods which may be chained after r.Add to restrict the route
+var routeMethods = map[string]string{
+	"Get":    "GET",
+	"Post":   "POST",
+	"Put":    "PUT",
+	"Patch":  "PATCH",
+	"Delete": "DELETE",
+}
+
+// RunRoutes lists the routes registered in the routes.go file and warns about duplicate or shadowed routes
+func RunRoutes(args []string) {
+	routesPath := appRoutesFilePath()
+	data, err := ioutil.ReadFile(routesPath)
+	if err != nil {
+		log.Printf("Error reading routes at:%s :%s", routesPath, err)
+		return
+	}
+
+	routes, err := parseRoutes(data)
+	if err != nil {
+		log.Printf("Error parsing routes at:%s :%s", routesPath, err)
+		return
+	}
+
+	fmt.Printf("Routes in %s\n\n", routesPath)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "METHOD\tPATTERN\tPACKAGE\tHANDLER")
+	for _, r := range routes {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Method, r.Pattern, r.Package, r.Handler)
+	}
+	w.Flush()
+
+	warnings := checkRoutes(routes)
+	if len(warnings) > 0 {
+		fmt.Println()
+		for _, warning := range warnings {
+			fmt.Println(warning)
+		}
+	}
+}
+
+// parseRoutes statically parses the go source of a routes file, and returns the routes added to the router in order
+func parseRoutes(src []byte) ([]appRoute, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "routes.go", src, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var routes []appRoute
+	ast.Inspect(file, func(n ast.Node) bool {
+		stmt, ok := n.(*ast.ExprStmt)
+		if !ok {
+			return true
+		}
+
+		call, ok := stmt.X.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+
+		route := appRoute{Method: "GET", Line: fset.Position(stmt.Pos()).Line}
+
+		// Unwind any method calls chained after Add, e.g. r.Add(...).Post()
+		for {
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			if sel.Sel.Name == "Add" {
+				break
+			}
+			method, ok := routeMethods[sel.Sel.Name]
+			if !ok {
+				return true
+			}
+			route.Method = method
+			call, ok = sel.X.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+		}
+
+		if len(call.Args) < 2 {
+			return true
+		}
+
+		route.Pattern = exprString(fset, call.Args[0])
+		lit, ok := call.Args[0].(*ast.BasicLit)
+		if ok && lit.Kind == token.STRING {
+			route.Pattern, _ = strconv.Unquote(lit.Value)
+		}
+
+		route.Handler = exprString(fset, call.Args[1])
+		sel, ok := call.Args[1].(*ast.SelectorExpr)
+		if ok {
+			ident, ok := sel.X.(*ast.Ident)
+			if ok {
+				route.PackageName = ident.Name
+				route.Package = importForName(file, ident.Name)
+				route.Handler = sel.Sel.Name
+			}
+		}
+
+		routes = append(routes, route)
+		return false
+	})
+
+	return routes, nil
+}
+
+// importForName returns the import path for a package name used in file, or the name if it cannot be found
+func importForName(file *ast.File, name string) string {
+	for _, i := range file.Imports {
+		p, _ := strconv.Unquote(i.Path.Value)
+		if i.Name != nil {
+			if i.Name.Name == name {
+				return p
+			}
+			continue
+		}
+
+		// Generated resource handlers are imported as e.g. plurals/actions and named resourceactions
+		base := path.Base(p)
+		if base == name || (strings.HasSuffix(name, base) &&
+			path.Base(path.Dir(p)) == ToPlural(strings.TrimSuffix(name, base))) {
+			return p
+		}
+	}
+
+	return name
+}
+
+// exprString returns the source for an expression
+func exprString(fset *token.FileSet, expr ast.Expr) string {
+	var b bytes.Buffer
+	printer.Fprint(&b, fset, expr)
+	return b.String()
+}
+
+// routeParamRegexp matches the params in route patterns e.g. {id:[0-9]+}, the pattern of a param may contain
+// counts in braces e.g. {id:[0-9a-f]{8}}
+var routeParamRegexp = regexp.MustCompile(`\{(\w+)(?::((?:[^{}]|\{[^{}]*\})+))?\}`)
+
+// routeRegexp returns a regexp matching the paths a route pattern would match
+func routeRegexp(pattern string) (*regexp.Regexp, error) {
+	expr := ""
+	last := 0
+	for _, m := range routeParamRegexp.FindAllStringSubmatchIndex(pattern, -1) {
+		expr += regexp.QuoteMeta(pattern[last:m[0]])
+		if m[4] >= 0 {
+			expr += "(" + pattern[m[4]:m[5]] + ")"
+		} else {
+			expr += "([^/]+)"
+		}
+		last = m[1]
+	}
+	expr += regexp.QuoteMeta(pattern[last:])
+
+	return regexp.Compile("^" + expr + "$")
+}
+
+// checkRoutes returns warnings for routes which duplicate an earlier route,
+// or which can never be reached because an earlier route with parameters matches them first
+func checkRoutes(routes []appRoute) []string {
+	var warnings []string
+
+	for i, r := range routes {
+		// Param names do not affect matching, so compare patterns without them
+		normalised := routeParamRegexp.ReplaceAllString(r.Pattern, "{$2}")
+
+		for _, earlier := range routes[:i] {
+			if earlier.Method != r.Method {
+				continue
+			}
+
+			if routeParamRegexp.ReplaceAllString(earlier.Pattern, "{$2}") == normalised {
+				warnings = append(warnings, fmt.Sprintf("Warning: %s %s on line %d duplicates the route on line %d", r.Method, r.Pattern, r.Line, earlier.Line))
+				break
+			}
+
+			if routeParamRegexp.MatchString(r.Pattern) {
+				continue
+			}
+			re, err := routeRegexp(earlier.Pattern)
+			if err == nil && re.MatchString(r.Pattern) {
+				warnings = append(warnings, fmt.Sprintf("Warning: %s %s on line %d is shadowed by %s on line %d", r.Method, r.Pattern, r.Line, earlier.Pattern, earlier.Line))
+				break
+			}
+		}
+	}
+
+	return warnings
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+const testRoutesSrc = `package app
+
+import (
+	"github.com/fragmenta/router"
+
+	"example.com/app/src/pages/actions"
+)
+
+// SetupRoutes adds the routes for the app
+func SetupRoutes(r *router.Router) {
+	r.Add("/", pageactions.HandleHome)
+	r.Add("/pages", pageactions.HandleIndex)
+}
+`
+
+const testCommentRoutes = `
+    r.Add("/pages/{page_id:[0-9]+}/comments", commentactions.HandleIndex)
+    r.Add("/pages/{page_id:[0-9]+}/comments/create", commentactions.HandleCreate).Post()`
+
+var insertRoutesTests = []struct {
+	name     string
+	src      string
+	funcName string
+	want     []string
+	err      string
+}{
+	{
+		name:     "import block",
+		src:      testRoutesSrc,
+		funcName: "SetupRoutes",
+		want: []string{
+			"\t\"example.com/app/src/comments/actions\"\n",
+			"\tr.Add(\"/pages/{page_id:[0-9]+}/comments/create\", commentactions.HandleCreate).Post()\n",
+			"\tr.Add(\"/pages\", pageactions.HandleIndex)\n",
+		},
+	},
+	{
+		name: "no import block",
+		src: `package app
+
+func SetupRoutes(r *router.Router) {
+}
+`,
+		funcName: "SetupRoutes",
+		want: []string{
+			"import \"example.com/app/src/comments/actions\"\n",
+			"\tr.Add(\"/pages/{page_id:[0-9]+}/comments\", commentactions.HandleIndex)\n",
+		},
+	},
+	{
+		name: "single import",
+		src: `package app
+
+import "github.com/fragmenta/router"
+
+func SetupRoutes(r *router.Router) {
+}
+`,
+		funcName: "SetupRoutes",
+		want: []string{
+			"import \"example.com/app/src/comments/actions\"\n",
+			"import \"github.com/fragmenta/router\"\n",
+		},
+	},
+	{
+		name: "router param name",
+		src: `package app
+
+import (
+	"github.com/fragmenta/router"
+)
+
+func setup(router *router.Router) {
+}
+`,
+		funcName: "setup",
+		want: []string{
+			"\trouter.Add(\"/pages/{page_id:[0-9]+}/comments\", commentactions.HandleIndex)\n",
+		},
+	},
+	{
+		name: "aliased import",
+		src: `package app
+
+import (
+	ca "example.com/app/src/comments/actions"
+)
+
+func SetupRoutes(r *router.Router) {
+	r.Add("/comments", ca.HandleIndex)
+}
+`,
+		funcName: "SetupRoutes",
+		err:      "routes already exist",
+	},
+	{
+		name:     "missing func",
+		src:      testRoutesSrc,
+		funcName: "setupRoutes",
+		err:      "no func setupRoutes found",
+	},
+	{
+		name: "missing router param",
+		src: `package app
+
+func SetupRoutes() {
+}
+`,
+		funcName: "SetupRoutes",
+		err:      "has no router param",
+	},
+}
+
+func TestInsertRoutes(t *testing.T) {
+	for _, tt := range insertRoutesTests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := insertRoutes([]byte(tt.src), tt.funcName, testCommentRoutes, "example.com/app/src/comments/actions")
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected error %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error inserting routes %s", err)
+			}
+
+			_, err = parser.ParseFile(token.NewFileSet(), "routes.go", output, 0)
+			if err != nil {
+				t.Fatalf("error parsing routes %s\n%s", err, output)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(output), want) {
+					t.Errorf("expected routes to contain %q, got\n%s", want, output)
+				}
+			}
+		})
+	}
+}
+
+var removeRoutesTests = []struct {
+	name     string
+	src      string
+	packages map[string]string
+	removed  int
+	want     []string
+	unwanted []string
+}{
+	{
+		name:     "routes and import",
+		src:      testRoutesSrc,
+		packages: map[string]string{"example.com/app/src/pages/actions": "pageactions"},
+		removed:  2,
+		want:     []string{"\"github.com/fragmenta/router\"", "func SetupRoutes(r *router.Router) {"},
+		unwanted: []string{"pages/actions", "pageactions"},
+	},
+	{
+		name: "aliased import",
+		src: `package app
+
+import (
+	"github.com/fragmenta/router"
+
+	pa "example.com/app/src/pages/actions"
+	"example.com/app/src/users/actions"
+)
+
+func SetupRoutes(r *router.Router) {
+	r.Add("/pages", pa.HandleIndex)
+	r.Add("/pages/create", pa.HandleCreate).Post()
+	r.Add("/users", useractions.HandleIndex)
+}
+`,
+		packages: map[string]string{"example.com/app/src/pages/actions": "pageactions"},
+		removed:  2,
+		want:     []string{"example.com/app/src/users/actions", "useractions.HandleIndex"},
+		unwanted: []string{"pages/actions", "pa.Handle"},
+	},
+	{
+		name: "nested routes",
+		src: `package app
+
+import (
+	"github.com/fragmenta/router"
+
+	"example.com/app/src/comments/actions"
+	"example.com/app/src/pages/actions"
+)
+
+func SetupRoutes(r *router.Router) {
+	r.Add("/pages", pageactions.HandleIndex)
+	r.Add("/pages/{page_id:[0-9]+}/comments", commentactions.HandleIndex)
+	if r != nil {
+		r.Add("/pages/{page_id:[0-9]+}/comments/create", commentactions.HandleCreate).Post()
+	}
+}
+`,
+		packages: map[string]string{"example.com/app/src/comments/actions": "commentactions"},
+		removed:  2,
+		want:     []string{"example.com/app/src/pages/actions", "r.Add(\"/pages\", pageactions.HandleIndex)", "if r != nil {"},
+		unwanted: []string{"comments/actions", "commentactions"},
+	},
+	{
+		name:     "no routes",
+		src:      testRoutesSrc,
+		packages: map[string]string{"example.com/app/src/comments/actions": "commentactions"},
+		removed:  0,
+		want:     []string{testRoutesSrc},
+	},
+}
+
+func TestRemoveRoutes(t *testing.T) {
+	for _, tt := range removeRoutesTests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, removed, err := removeRoutes([]byte(tt.src), tt.packages)
+			if err != nil {
+				t.Fatalf("error removing routes %s", err)
+			}
+			if removed != tt.removed {
+				t.Errorf("expected %d routes removed, got %d", tt.removed, removed)
+			}
+
+			_, err = parser.ParseFile(token.NewFileSet(), "routes.go", output, 0)
+			if err != nil {
+				t.Fatalf("error parsing routes %s\n%s", err, output)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(output), want) {
+					t.Errorf("expected routes to contain %q, got\n%s", want, output)
+				}
+			}
+			for _, unwanted := range tt.unwanted {
+				if strings.Contains(string(output), unwanted) {
+					t.Errorf("expected routes not to contain %q, got\n%s", unwanted, output)
+				}
+			}
+		})
+	}
+}
+
+var checkRoutesTests = []struct {
+	name     string
+	patterns []string
+	warnings []string
+}{
+	{
+		name:     "no warnings",
+		patterns: []string{"/pages", "/pages/create", "/pages/{id:[0-9]+}", "/pages/{id:[0-9]+}/update"},
+	},
+	{
+		name:     "duplicate",
+		patterns: []string{"/pages/{id:[0-9]+}", "/pages/{page_id:[0-9]+}"},
+		warnings: []string{"/pages/{page_id:[0-9]+} on line 2 duplicates the route on line 1"},
+	},
+	{
+		name:     "shadowed",
+		patterns: []string{"/pages/{id:[0-9]+}", "/pages/42"},
+		warnings: []string{"/pages/42 on line 2 is shadowed by /pages/{id:[0-9]+} on line 1"},
+	},
+	{
+		name:     "counted pattern shadows",
+		patterns: []string{"/pages/{id:[0-9a-f]{8}}", "/pages/deadbeef"},
+		warnings: []string{"/pages/deadbeef on line 2 is shadowed by /pages/{id:[0-9a-f]{8}} on line 1"},
+	},
+	{
+		name:     "counted pattern does not shadow",
+		patterns: []string{"/pages/{id:[0-9a-f]{8}}", "/pages/create", "/pages/abcdef123"},
+	},
+	{
+		name:     "counted pattern duplicate",
+		patterns: []string{"/pages/{id:" + uuidIDPattern + "}", "/pages/{page_id:" + uuidIDPattern + "}"},
+		warnings: []string{"on line 2 duplicates the route on line 1"},
+	},
+	{
+		name:     "uuid pattern shadows",
+		patterns: []string{"/pages/{id:" + uuidIDPattern + "}", "/pages/0b5b3e1c-8d2f-4c3a-9e4f-1a2b3c4d5e6f"},
+		warnings: []string{"is shadowed by /pages/{id:" + uuidIDPattern + "} on line 1"},
+	},
+}
+
+func TestCheckRoutes(t *testing.T) {
+	for _, tt := range checkRoutesTests {
+		t.Run(tt.name, func(t *testing.T) {
+			var routes []appRoute
+			for i, p := range tt.patterns {
+				routes = append(routes, appRoute{Method: "GET", Pattern: p, Line: i + 1})
+			}
+
+			warnings := checkRoutes(routes)
+			if len(warnings) != len(tt.warnings) {
+				t.Fatalf("expected %d warnings, got %v", len(tt.warnings), warnings)
+			}
+			for i, want := range tt.warnings {
+				if !strings.Contains(warnings[i], want) {
+					t.Errorf("expected warning %q, got %q", want, warnings[i])
+				}
+			}
+		})
+	}
+
+	// Routes with different methods do not shadow each other
+	routes := []appRoute{
+		{Method: "GET", Pattern: "/pages/{id:[0-9]+}", Line: 1},
+		{Method: "POST", Pattern: "/pages/42", Line: 2},
+	}
+	if warnings := checkRoutes(routes); len(warnings) > 0 {
+		t.Errorf("expected no warnings for different methods, got %v", warnings)
+	}
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/fragmenta/query"
+)
+
+// Columns added to every resource table by generate, which are not given as fields
+var defaultColumns = []string{"id", "created_at", "updated_at"}
+
+// tableColumns reads the columns of table from the open database, and returns a map of column name to database type
+// The type of arrays is the type of their elements followed by [] e.g. integer[]
+func tableColumns(table string) (map[string]string, error) {
+	cols := make(map[string]string, 0)
+
+	sql := "select column_name, data_type, udt_name from information_schema.columns where table_name=$1 order by ordinal_position;"
+	rows, err := query.QuerySQL(sql, table)
+	if err != nil {
+		return cols, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var name, dataType, udtName string
+		err := rows.Scan(&name, &dataType, &udtName)
+		if err != nil {
+			return cols, err
+		}
+		if dataType == "ARRAY" {
+			dataType = arrayType(udtName)
+		}
+		cols[name] = dataType
+	}
+
+	if len(cols) == 0 {
+		return cols, fmt.Errorf("no columns found for table %s", table)
+	}
+
+	return cols, nil
+}
+
+// The data types of postgres internal type names, used for the elements of arrays
+var udtTypes = map[string]string{
+	"text":        "text",
+	"varchar":     "character varying",
+	"bpchar":      "character",
+	"int2":        "smallint",
+	"int4":        "integer",
+	"int8":        "bigint",
+	"float4":      "real",
+	"float8":      "double precision",
+	"numeric":     "numeric",
+	"bool":        "boolean",
+	"timestamp":   "timestamp without time zone",
+	"timestamptz": "timestamp with time zone",
+	"date":        "date",
+	"json":        "json",
+	"jsonb":       "jsonb",
+	"uuid":        "uuid",
+}
+
+// arrayType returns the type of an array column from its udt_name in information_schema e.g. _int4 is integer[]
+func arrayType(udtName string) string {
+	element := strings.TrimPrefix(udtName, "_")
+	if t, ok := udtTypes[element]; ok {
+		element = t
+	}
+	return element + "[]"
+}
+
+// Convert a database type from information_schema back to a generator field type
+func fromSQLType(dataType string) (string, bool) {
+	switch strings.ToLower(dataType) {
+	case "text", "character varying", "character", "varchar", "char":
+		return "text", true
+	case "integer", "smallint":
+		return "int", true
+	case "bigint":
+		return "bigint", true
+	case "real":
+		return "float", true
+	case "double precision":
+		return "double", true
+	case "numeric":
+		return "decimal", true
+	case "timestamp", "timestamp without time zone", "timestamp with time zone":
+		return "time", true
+	case "date":
+		return "date", true
+	case "boolean":
+		return "bool", true
+	case "json", "jsonb", "uuid":
+		return strings.ToLower(dataType), true
+	case "text[]", "character varying[]", "character[]":
+		return "text[]", true
+	}
+
+	return dataType, false
+}
+
+// generateResourceFromTable generates a resource for an existing table, reading the columns from the development db
+// the table defaults to the plural of the resource name, and no migration is generated
+func generateResourceFromTable(args []string) {
+	resourceName = ToSingular(args[0])
+	columns = make(map[string]string, 0)
+	columnModifiers = make(map[string][]string, 0)
+
+	table := generateFlagValue("from-table")
+	if table == "" || table == "true" {
+		table = ToPlural(resourceName)
+	}
+	resourceTable = table
+
+	err := openDatabase(ConfigDevelopment)
+	if err != nil {
+		log.Printf("Error opening database %s", err)
+		return
+	}
+
+	cols, err := tableColumns(table)
+	if err != nil {
+		log.Printf("Error reading table %s", err)
+		return
+	}
+
+	// Use uuid keys in the resource if the table has a uuid id
+	generateFlags["uuid"] = strconv.FormatBool(cols["id"] == "uuid")
+
+	for name, dataType := range cols {
+		if contains(name, defaultColumns) || name == searchColumn {
+			continue
+		}
+
+		fieldType, ok := fromSQLType(dataType)
+		if !ok {
+			fmt.Printf("Unknown type %s for column %s, using text\n", dataType, name)
+			fieldType = "text"
+		}
+		columns[name] = fieldType
+	}
+
+	fmt.Printf("Generating resource from table %s with\n - name:%s\n - attributes:%v\n", table, resourceName, columns)
+
+	generateResourceRoutes("")
+	generateResourceFiles()
+	generateResourceTests()
+	generateOpenAPI()
+	syncAdmin()
+}
+
+// Convert a go struct field type back to a generator field type
+func fromGoType(goType string) (string, bool) {
+	switch goType {
+	case "string":
+		return "text", true
+	case "int", "int64", "int32":
+		return "int", true
+	case "float64", "float32":
+		return "double", true
+	case "time.Time":
+		return "time", true
+	case "bool":
+		return "bool", true
+	case "[]string":
+		return "text[]", true
+	}
+
+	return goType, false
+}
+
+// generateAutoMigration compares the model structs in each resource package with the tables in the development db,
+// and generates a migration to add, remove or change the type of columns so that the tables match the models
+// Changes which may lose data are flagged for review in the migration
+func generateAutoMigration() {
+	err := openDatabase(ConfigDevelopment)
+	if err != nil {
+		log.Printf("Error opening database %s", err)
+		return
+	}
+
+	srcPath := path.Join(fullAppPath(), appGeneratePath())
+	entries, err := ioutil.ReadDir(srcPath)
+	if err != nil {
+		log.Printf("Error reading resources at %s %s", srcPath, err)
+		return
+	}
+
+	sql := ""
+	destructive := 0
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+
+		resourcePath := path.Join(srcPath, e.Name())
+		name, fields := modelFields(resourcePath)
+		if name == "" {
+			continue
+		}
+
+		table := modelTableName(resourcePath)
+		if table == "" {
+			table = e.Name()
+		}
+
+		cols, err := tableColumns(table)
+		if err != nil {
+			fmt.Printf("Skipping %s, table %s not found in database\n", name, table)
+			continue
+		}
+
+		tableSQL, tableDestructive := alterTableSQL(table, fields, cols)
+		sql += tableSQL
+		destructive += tableDestructive
+	}
+
+	if sql == "" {
+		fmt.Println("Models match the database, no migration required")
+		return
+	}
+
+	if destructive > 0 {
+		fmt.Printf("Warning: %d changes may lose data, review the migration before running it\n", destructive)
+	}
+
+	generateMigration("Auto-Schema", "/* SQL migration generated from model structs */\n"+sql)
+}
+
+// alterTableSQL returns sql to change table from the database columns cols to match the model fields,
+// and the number of destructive changes it contains
+func alterTableSQL(table string, fields map[string]string, cols map[string]string) (string, int) {
+	sql := ""
+	destructive := 0
+
+	for _, col := range sortedKeys(fields) {
+		fieldType, ok := fromGoType(fields[col])
+		if !ok {
+			sql += fmt.Sprintf("/* REVIEW: unknown type %s for %s.%s */\n", fields[col], table, col)
+			continue
+		}
+
+		dataType, exists := cols[col]
+		if !exists {
+			sql += fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s;\n", table, col, toSQLType(fieldType))
+			continue
+		}
+
+		// Compare the go types, as several database types map to the same go type
+		dbType, _ := fromSQLType(dataType)
+		if toGoType(dbType) != toGoType(fieldType) {
+			sqlType := toSQLType(fieldType)
+			sql += fmt.Sprintf("/* REVIEW: destructive, changes the type of %s.%s from %s to %s */\n", table, col, dataType, sqlType)
+			sql += fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s USING %s::%s;\n", table, col, sqlType, col, sqlType)
+			destructive++
+		}
+	}
+
+	for _, col := range sortedKeys(cols) {
+		if _, ok := fields[col]; ok || contains(col, defaultColumns) || col == searchColumn {
+			continue
+		}
+		sql += fmt.Sprintf("/* REVIEW: destructive, drops the data in %s.%s */\n", table, col)
+		sql += fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;\n", table, col)
+		destructive++
+	}
+
+	return sql, destructive
+}
+
+// modelTableName parses the go files in the resource package at resourcePath,
+// and returns the table name assigned to TableName, or an empty string if none is found
+func modelTableName(resourcePath string) string {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, resourcePath, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return ""
+	}
+
+	table := ""
+	for _, pkg := range pkgs {
+		ast.Inspect(pkg, func(n ast.Node) bool {
+			assign, ok := n.(*ast.AssignStmt)
+			if !ok || len(assign.Lhs) != 1 || len(assign.Rhs) != 1 {
+				return true
+			}
+			sel, ok := assign.Lhs[0].(*ast.SelectorExpr)
+			lit, isLit := assign.Rhs[0].(*ast.BasicLit)
+			if ok && isLit && sel.Sel.Name == "TableName" && lit.Kind == token.STRING {
+				table, _ = strconv.Unquote(lit.Value)
+				return false
+			}
+			return true
+		})
+	}
+
+	return table
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The tsvector column added to the tables of resources with searchable columns
+const searchColumn = "search_vector"
+
+// The text search configuration used to index and query searchable columns
+const searchConfig = "pg_catalog.english"
+
+// searchableColumns returns the columns with the searchable modifier, sorted by name
+func searchableColumns() []string {
+	var cols []string
+	for _, k := range sortedKeys(columns) {
+		if contains("searchable", columnModifiers[k]) {
+			cols = append(cols, k)
+		}
+	}
+	return cols
+}
+
+// checkSearchable returns an error if the searchable modifier is given for a column which is not text
+// The sql type is checked, as tsvector_update_trigger only accepts character columns, while json and uuid are also strings in go
+func checkSearchable() error {
+	for _, k := range searchableColumns() {
+		if !isTextSQLType(toSQLType(columns[k])) || toInputType(columns[k]) == "file" {
+			return fmt.Errorf("column %s of type %s cannot be searchable, only text, varchar and char columns can be searched", k, columns[k])
+		}
+	}
+	return nil
+}
+
+// isTextSQLType returns true if sqlType is a character type e.g. text, varchar(255) or char(3)
+func isTextSQLType(sqlType string) bool {
+	name := strings.TrimSpace(strings.ToLower(sqlType))
+	if i := strings.Index(name, "("); i >= 0 {
+		name = strings.TrimSpace(name[:i])
+	}
+
+	switch name {
+	case "text", "varchar", "character varying", "char", "character":
+		return true
+	}
+	return false
+}
+
+// searchMigrationSQL returns sql to add a tsvector column to the resource table, kept up to date by a trigger
+// from the searchable columns and with a GIN index, or an empty string if there are no searchable columns
+func searchMigrationSQL() string {
+	cols := searchableColumns()
+	if len(cols) == 0 {
+		return ""
+	}
+
+	tmpl := `ALTER TABLE [[.table]] ADD COLUMN [[.column]] tsvector;
+CREATE INDEX ON [[.table]] USING GIN ([[.column]]);
+CREATE TRIGGER [[.table]]_[[.column]]_update BEFORE INSERT OR UPDATE ON [[.table]]
+FOR EACH ROW EXECUTE PROCEDURE tsvector_update_trigger([[.column]], '[[.config]]', [[.columns]]);
+`
+	context := map[string]string{
+		"table":   resourceTableName(),
+		"column":  searchColumn,
+		"config":  searchConfig,
+		"columns": strings.Join(cols, ", "),
+	}
+	return renderTemplate(tmpl, context)
+}
+
+// Generate the Search function for the model, or an empty string if there are no searchable columns
+func searchFunc() string {
+	cols := searchableColumns()
+	if len(cols) == 0 {
+		return ""
+	}
+
+	tmpl := `
+// Search returns a query for the [[.fragmenta_resources]] with [[.columns]] matching the text q
+func Search(q string) *query.Query {
+	return Query().Where("[[.column]] @@ plainto_tsquery('[[.config]]', ?)", q)
+}
+`
+	context := map[string]string{
+		"fragmenta_resources": ToPlural(resourceName),
+		"columns":             strings.Join(cols, " or "),
+		"column":              searchColumn,
+		"config":              searchConfig,
+	}
+	return renderTemplate(tmpl, context)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"testing"
+)
+
+var searchableTests = []struct {
+	fieldType string
+	valid     bool
+}{
+	{"text", true},
+	{"string", true},
+	{"char(255)", true},
+	{"enum(a,b)", true},
+	{"json", false},
+	{"jsonb", false},
+	{"uuid", false},
+	{"int", false},
+	{"text[]", false},
+	{"image", false},
+}
+
+func TestCheckSearchable(t *testing.T) {
+	defer func() {
+		columns = nil
+		columnModifiers = nil
+	}()
+
+	for _, tt := range searchableTests {
+		columns = map[string]string{"col": tt.fieldType}
+		columnModifiers = map[string][]string{"col": {"searchable"}}
+
+		err := checkSearchable()
+		if tt.valid && err != nil {
+			t.Errorf("checkSearchable(%s) error %s", tt.fieldType, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("checkSearchable(%s) accepted a column which cannot be searched", tt.fieldType)
+		}
+	}
+}
+
+func TestIsTextSQLType(t *testing.T) {
+	for sqlType, want := range map[string]bool{
+		"text":                   true,
+		"varchar(100)":           true,
+		"character varying(100)": true,
+		"CHAR (3)":               true,
+		"uuid":                   false,
+		"jsonb":                  false,
+		"text[]":                 false,
+	} {
+		if got := isTextSQLType(sqlType); got != want {
+			t.Errorf("isTextSQLType(%q) = %t, want %t", sqlType, got, want)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"strings"
+)
+
+// defaultTemplates holds the templates used by generate when the app does not have its own in src/lib/templates
+//
+//go:embed templates
+var defaultTemplates embed.FS
+
+// RunTemplates manages the generator templates in the app
+// Expects:
+// - templates eject
+// - templates eject fragmenta_resources
+func RunTemplates(args []string) {
+	// Remove fragmenta templates from args list
+	args = args[2:]
+
+	if len(args) < 1 {
+		fmt.Println("Not enough arguments")
+		return
+	}
+
+	switch args[0] {
+	case "eject":
+		names := args[1:]
+		if len(names) == 0 {
+			names = defaultTemplateSets()
+		}
+		for _, name := range names {
+			ejectTemplates(name)
+		}
+	default:
+		fmt.Println("Sorry, I didn't recognise that argument, you can use fragmenta templates [eject]")
+	}
+}
+
+// templateSet returns the named set of templates from the app,
+// or the default templates embedded in fragmenta if the app has none
+func templateSet(name string) fs.FS {
+	srcPath := appTemplateSetPath(name)
+
+	_, err := os.Stat(srcPath)
+	if err == nil {
+		log.Printf("Using templates at %s", srcPath)
+		return os.DirFS(srcPath)
+	}
+
+	log.Printf("No template files at %s, using default templates", srcPath)
+	templates, err := fs.Sub(defaultTemplates, path.Join("templates", name))
+	if err != nil {
+		log.Fatal("Error reading default templates ", name)
+	}
+	return templates
+}
+
+// defaultTemplateSets returns the names of the sets of templates embedded in fragmenta
+func defaultTemplateSets() []string {
+	var names []string
+	entries, err := defaultTemplates.ReadDir("templates")
+	if err != nil {
+		return names
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	return names
+}
+
+// ejectTemplates copies the named set of default templates into the app, so that they can be customised
+// Templates are copied without reifying them, as they are used by later calls to generate
+func ejectTemplates(name string) {
+	root := path.Join("templates", name)
+	dstPath := appTemplateSetPath(name)
+
+	_, err := fs.Stat(defaultTemplates, root)
+	if err != nil {
+		fmt.Printf("No default templates named %s, choose from: %s\n", name, strings.Join(defaultTemplateSets(), ", "))
+		return
+	}
+
+	fmt.Printf("Ejecting %s templates to %s\n", name, dstPath)
+
+	err = fs.WalkDir(defaultTemplates, root, func(fileSrc string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		data, err := defaultTemplates.ReadFile(fileSrc)
+		if err != nil {
+			return err
+		}
+
+		fileDst := path.Join(dstPath, strings.TrimPrefix(fileSrc, root))
+		written, err := writeGeneratedFile(fileDst, data)
+		if written {
+			log.Printf("=> %s\n", fileDst)
+		}
+		return err
+	})
+
+	if err != nil {
+		fmt.Printf("Error ejecting templates %s\n", err)
+	}
+}
@@ -2,7 +2,14 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 // Truncate the given string to length using … as ellipsis.
@@ -11,14 +18,54 @@ func Truncate(s string, length int) string {
 }
 
 // TruncateWithEllipsis truncates the given string to length using provided ellipsis.
+// The length is counted in characters as they are displayed, including the ellipsis, so multi-byte characters,
+// accents and emoji are never cut in half
 func TruncateWithEllipsis(s string, length int, ellipsis string) string {
+	chars := graphemes(s)
+	if len(chars) <= length {
+		return s
+	}
+
+	ellipsisChars := graphemes(ellipsis)
+	if length <= len(ellipsisChars) {
+		if length < 0 {
+			length = 0
+		}
+		return strings.Join(ellipsisChars[:length], "")
+	}
+	return strings.Join(chars[:length-len(ellipsisChars)], "") + ellipsis
+}
 
-	l := len(s)
-	el := len(ellipsis)
-	if l+el > length {
-		s = string(s[0:length-el]) + ellipsis
+// The zero width joiner, which joins emoji into a single character e.g. a family
+const zeroWidthJoiner = '\u200d'
+
+// graphemes splits s into characters as they are displayed, keeping combining marks, variation selectors,
+// emoji modifiers, zero width joined sequences and pairs of regional indicators (flags) with the character they modify
+func graphemes(s string) []string {
+	var chars []string
+	start := 0
+	prev := rune(-1)
+	regionalIndicators := 0
+	for i, c := range s {
+		if unicode.Is(unicode.Regional_Indicator, c) {
+			regionalIndicators++
+		} else {
+			regionalIndicators = 0
+		}
+
+		extends := unicode.In(c, unicode.Mn, unicode.Me, unicode.Mc, unicode.Variation_Selector) ||
+			(c >= 0x1F3FB && c <= 0x1F3FF) || c == zeroWidthJoiner || prev == zeroWidthJoiner ||
+			(regionalIndicators > 0 && regionalIndicators%2 == 0)
+		if i > 0 && !extends {
+			chars = append(chars, s[start:i])
+			start = i
+		}
+		prev = c
+	}
+	if start < len(s) {
+		chars = append(chars, s[start:])
 	}
-	return s
+	return chars
 }
 
 // ToPlural provides the plural version of an English word using some simple rules and a table of exceptions.
@@ -32,13 +79,27 @@ func ToPlural(text string) (plural string) {
 		return translations[word]
 	}
 
+	// Then words which ToSingular does not leave to the rules, so that it is consistent with it e.g. quiz and news
+	if singulars[word] != "" {
+		return singulars[word]
+	}
+
+	// Words which are already a known plural are returned unchanged e.g. quizzes and people
+	for _, table := range []map[string]string{translations, singulars} {
+		for _, plural := range table {
+			if plural == word {
+				return word
+			}
+		}
+	}
+
 	// If we have no translation, just follow some basic rules - avoid new rules if possible
-	if strings.HasSuffix(word, "s") || strings.HasSuffix(word, "z") || strings.HasSuffix(word, "h") {
+	if hasSuffix(word, "s", "z", "x", "ch", "sh") {
 		plural = word + "es"
-	} else if strings.HasSuffix(word, "y") {
-		plural = strings.TrimRight(word, "y") + "ies"
+	} else if strings.HasSuffix(word, "y") && !hasSuffix(word, "ay", "ey", "iy", "oy", "uy") {
+		plural = strings.TrimSuffix(word, "y") + "ies"
 	} else if strings.HasSuffix(word, "um") {
-		plural = strings.TrimRight(word, "um") + "a"
+		plural = strings.TrimSuffix(word, "um") + "a"
 	} else {
 		plural = word + "s"
 	}
@@ -46,10 +107,66 @@ func ToPlural(text string) (plural string) {
 	return plural
 }
 
+// ToSingular provides the singular version of an English plural using the translations and some simple rules.
+// It is not an exact inverse of ToPlural - words which are not recognised as a plural are returned unchanged,
+// so that a singular may be passed in, and singulars the rules would mangle are listed in singulars
+func ToSingular(text string) string {
+
+	// We only deal with lowercase
+	word := strings.ToLower(text)
+
+	// Check translations first, words which translate to themselves are both singular and plural
+	if translations[word] != "" {
+		return word
+	}
+	for singular, plural := range translations {
+		if plural == word {
+			return singular
+		}
+	}
+
+	// Then words which the rules below would get wrong
+	if singulars[word] != "" {
+		return word
+	}
+	for singular, plural := range singulars {
+		if plural == word {
+			return singular
+		}
+	}
+
+	// Words which do not end in s, or end in ss, us or is are singular e.g. class, status, axis
+	if !strings.HasSuffix(word, "s") || hasSuffix(word, "ss", "us", "is") {
+		return word
+	}
+
+	switch {
+	case strings.HasSuffix(word, "ies"):
+		return strings.TrimSuffix(word, "ies") + "y"
+	case hasSuffix(word, "sses", "xes", "ches", "shes", "zzes", "oes"):
+		return strings.TrimSuffix(word, "es")
+	case strings.HasSuffix(word, "uses") && !hasSuffix(word, "auses", "euses", "iuses", "ouses", "uuses") && len(word) > 4:
+		// e.g. statuses and buses, but not causes or houses
+		return strings.TrimSuffix(word, "es")
+	}
+
+	return strings.TrimSuffix(word, "s")
+}
+
+// hasSuffix returns true if s ends with any of the suffixes
+func hasSuffix(s string, suffixes ...string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(s, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
 // Which irregulars are important or correct depends on your usage of English
 // Some of those below are now considered old-fashioned and many more could be added
 // As this is used for database models, it only needs a limited subset of all irregulars
-// NB you should not attempt to reverse and singularize, but just use the singular provided
+// Projects may add or override translations in config or src/lib/inflections.json, see registerInflections
 var translations = map[string]string{
 	"hero":        "heroes",
 	"supernova":   "supernovae",
@@ -81,19 +198,162 @@ var translations = map[string]string{
 	// ..etc
 }
 
+// Singulars which ToSingular leaves unchanged though the rules would treat them as plurals,
+// mapped to their plurals which the rules would not singularise correctly e.g. lens, zombies and quizzes
+// ToPlural uses the same table, so that the plurals of these words survive a round trip through ToSingular
+var singulars = map[string]string{
+	"alias":   "aliases",
+	"atlas":   "atlases",
+	"bias":    "biases",
+	"canvas":  "canvases",
+	"gas":     "gases",
+	"lens":    "lenses",
+	"news":    "news",
+	"series":  "series",
+	"quiz":    "quizzes",
+	"cache":   "caches",
+	"calorie": "calories",
+	"cookie":  "cookies",
+	"hoodie":  "hoodies",
+	"movie":   "movies",
+	"pie":     "pies",
+	"rookie":  "rookies",
+	"selfie":  "selfies",
+	"tie":     "ties",
+	"zombie":  "zombies",
+	"canoe":   "canoes",
+	"shoe":    "shoes",
+	"toe":     "toes",
+}
+
+// The file in a project which may add to or override the translations used by ToPlural and ToSingular
+var inflectionsPath = filepath.Join("src", "lib", "inflections.json")
+
+// registerInflections adds the translations in src/lib/inflections.json of the project, if it exists,
+// and then those in the inflections section of fragmenta.json, to the translations used by ToPlural and ToSingular
+// Each is given as a map of singular to plural:
+//
+//	"inflections": {"person": "persons", "cactus": "cacti"}
+func registerInflections(projectPath string, inflections map[string]string) error {
+	file, err := ioutil.ReadFile(filepath.Join(projectPath, inflectionsPath))
+	if err == nil {
+		var fileInflections map[string]string
+		err = json.Unmarshal(file, &fileInflections)
+		if err != nil {
+			return fmt.Errorf("error parsing %s %s", inflectionsPath, err)
+		}
+		addInflections(fileInflections)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	addInflections(inflections)
+	return nil
+}
+
+// addInflections adds a map of singular to plural to translations, replacing any existing translation of the singular
+func addInflections(inflections map[string]string) {
+	for singular, plural := range inflections {
+		translations[strings.ToLower(singular)] = strings.ToLower(plural)
+	}
+}
+
+// commonInitialisms are written in capitals in field names, as in go, so that user_id is UserID rather than UserId
+var commonInitialisms = map[string]bool{
+	"ACL": true, "API": true, "ASCII": true, "CPU": true, "CSS": true, "DNS": true, "EOF": true, "GUID": true,
+	"HTML": true, "HTTP": true, "HTTPS": true, "ID": true, "IP": true, "JSON": true, "LHS": true, "QPS": true,
+	"RAM": true, "RHS": true, "RPC": true, "SLA": true, "SMTP": true, "SQL": true, "SSH": true, "TCP": true,
+	"TLS": true, "TTL": true, "UDP": true, "UI": true, "UID": true, "URI": true, "URL": true, "UTF8": true,
+	"UUID": true, "VM": true, "XML": true, "XMPP": true, "XSRF": true, "XSS": true,
+}
+
 // ToSnake converts a string from struct field names to corresponding database column names (e.g. FieldName to field_name)
+// Initialisms are kept together, so that UserID is user_id and HTMLBody is html_body
 func ToSnake(text string) string {
-	b := bytes.NewBufferString("")
-	for i, c := range text {
-		if i > 0 && c >= 'A' && c <= 'Z' {
-			b.WriteRune('_')
+	var words []string
+	for _, w := range camelWords(text) {
+		words = append(words, splitInitialisms(w)...)
+	}
+	return strings.ToLower(strings.Join(words, "_"))
+}
+
+// camelWords splits a camel case name into words at each capital, keeping runs of capitals like HTML together,
+// except for the last capital of a run followed by a lower case letter, which starts the next word (e.g. HTML Body),
+// unless the letter is the s of a plural like URLs
+func camelWords(text string) []string {
+	var words []string
+	runes := []rune(text)
+	start := 0
+	for i, c := range runes {
+		if c == '_' {
+			if i > start {
+				words = append(words, string(runes[start:i]))
+			}
+			start = i + 1
+			continue
+		}
+		if i == start || !unicode.IsUpper(c) {
+			continue
+		}
+		prev := runes[i-1]
+		nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+		plural := i+1 < len(runes) && runes[i+1] == 's' && (i+2 == len(runes) || !unicode.IsLower(runes[i+2]))
+		if plural {
+			nextLower = false
+		}
+		if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	if start < len(runes) {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
+
+// splitInitialisms splits a word in capitals made of several initialisms e.g. APIURL into API and URL,
+// or returns the word unchanged if it is not in capitals. Letters which are not part of an initialism are words
+// of their own, with any digits after them, so that ABC (from a_b_c) is A, B and C
+// The s of a plural like APIURLs stays with the last word
+func splitInitialisms(word string) []string {
+	singular := strings.TrimSuffix(word, "s")
+	if singular != word && singular != "" && singular == strings.ToUpper(singular) {
+		words := splitInitialisms(singular)
+		words[len(words)-1] += "s"
+		return words
+	}
+
+	if word != strings.ToUpper(word) || commonInitialisms[word] {
+		return []string{word}
+	}
+
+	// Match the longest initialism at the start, or a single letter, and then the rest of the word
+	var words []string
+	for len(word) > 0 {
+		n := 0
+		for i := len(word); i > 1; i-- {
+			if commonInitialisms[word[:i]] {
+				n = i
+				break
+			}
 		}
-		b.WriteRune(c)
+		if n == 0 {
+			_, n = utf8.DecodeRuneInString(word)
+			for n < len(word) && word[n] >= '0' && word[n] <= '9' {
+				n++
+			}
+		}
+		words = append(words, word[:n])
+		word = word[n:]
 	}
-	return strings.ToLower(b.String())
+	return words
 }
 
 // ToCamel converts a string from database column names to corresponding struct field names (e.g. field_name to FieldName)
+// Words which are common initialisms are written in capitals, so that user_id is UserID and html_body is HTMLBody,
+// as are their plurals, so that user_ids is UserIDs
+// If private is true, the first word is left as it is, for lower camel case names (e.g. field_name to fieldName)
 func ToCamel(text string, private ...bool) string {
 	lowerCamel := false
 	if private != nil {
@@ -102,13 +362,19 @@ func ToCamel(text string, private ...bool) string {
 	b := bytes.NewBufferString("")
 	s := strings.Split(text, "_")
 	for i, v := range s {
-		if len(v) > 0 {
-			s := v[:1]
-			if i > 0 || lowerCamel == false {
-				s = strings.ToUpper(s)
-			}
-			b.WriteString(s)
-			b.WriteString(v[1:])
+		if len(v) == 0 {
+			continue
+		}
+		if i == 0 && lowerCamel {
+			b.WriteString(v)
+		} else if commonInitialisms[strings.ToUpper(v)] {
+			b.WriteString(strings.ToUpper(v))
+		} else if singular := strings.TrimSuffix(v, "s"); singular != v && commonInitialisms[strings.ToUpper(singular)] {
+			b.WriteString(strings.ToUpper(singular) + "s")
+		} else {
+			c, size := utf8.DecodeRuneInString(v)
+			b.WriteRune(unicode.ToUpper(c))
+			b.WriteString(v[size:])
 		}
 	}
 	return b.String()
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"testing"
+)
+
+var singularTests = []struct {
+	in   string
+	want string
+}{
+	// Regular plurals
+	{"pages", "page"},
+	{"users", "user"},
+	{"categories", "category"},
+	{"keys", "key"},
+	{"classes", "class"},
+	{"boxes", "box"},
+	{"matches", "match"},
+	{"dishes", "dish"},
+	{"buzzes", "buzz"},
+	{"potatoes", "potato"},
+	{"statuses", "status"},
+	{"buses", "bus"},
+	{"causes", "cause"},
+	{"houses", "house"},
+	{"sizes", "size"},
+	{"photos", "photo"},
+	// Translations
+	{"people", "person"},
+	{"heroes", "hero"},
+	{"monies", "money"},
+	{"indices", "index"},
+	{"sheep", "sheep"},
+	{"species", "species"},
+	// Singulars are left alone
+	{"page", "page"},
+	{"status", "status"},
+	{"class", "class"},
+	{"axis", "axis"},
+	{"lens", "lens"},
+	{"canvas", "canvas"},
+	{"alias", "alias"},
+	{"gas", "gas"},
+	{"news", "news"},
+	{"series", "series"},
+	{"person", "person"},
+	// Plurals the rules would get wrong
+	{"lenses", "lens"},
+	{"aliases", "alias"},
+	{"zombies", "zombie"},
+	{"pies", "pie"},
+	{"movies", "movie"},
+	{"quizzes", "quiz"},
+	{"shoes", "shoe"},
+	{"caches", "cache"},
+	// Case is ignored
+	{"Pages", "page"},
+}
+
+func TestToSingular(t *testing.T) {
+	for _, tt := range singularTests {
+		got := ToSingular(tt.in)
+		if got != tt.want {
+			t.Errorf("ToSingular(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+var pluralTests = []struct {
+	in   string
+	want string
+}{
+	{"page", "pages"},
+	{"category", "categories"},
+	{"day", "days"},
+	{"key", "keys"},
+	{"class", "classes"},
+	{"box", "boxes"},
+	{"match", "matches"},
+	{"status", "statuses"},
+	{"millennium", "millennia"},
+	{"person", "people"},
+	{"money", "monies"},
+	{"index", "indices"},
+	{"Page", "pages"},
+	// Singulars the rules would get wrong, and words which are already plural
+	{"quiz", "quizzes"},
+	{"news", "news"},
+	{"series", "series"},
+	{"lens", "lenses"},
+	{"quizzes", "quizzes"},
+	{"people", "people"},
+}
+
+func TestToPlural(t *testing.T) {
+	for _, tt := range pluralTests {
+		got := ToPlural(tt.in)
+		if got != tt.want {
+			t.Errorf("ToPlural(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// roundTripTests are plurals which ToPlural should recreate from the result of ToSingular,
+// as resource names are singularised and table names pluralised again
+var roundTripTests = []string{
+	"pages",
+	"categories",
+	"keys",
+	"classes",
+	"boxes",
+	"buzzes",
+	"statuses",
+	"buses",
+	"causes",
+	"houses",
+	"photos",
+	"people",
+	"monies",
+	"indices",
+	"sheep",
+	"species",
+	"news",
+	"series",
+	"quizzes",
+	"lenses",
+	"aliases",
+	"zombies",
+	"movies",
+	"shoes",
+	"caches",
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, plural := range roundTripTests {
+		got := ToPlural(ToSingular(plural))
+		if got != plural {
+			t.Errorf("ToPlural(ToSingular(%q)) = %q, want %q", plural, got, plural)
+		}
+	}
+}
+
+func TestAddInflections(t *testing.T) {
+	// Add to a copy of the translations, and restore them afterwards
+	defaults := translations
+	defer func() { translations = defaults }()
+	translations = map[string]string{}
+	for singular, plural := range defaults {
+		translations[singular] = plural
+	}
+
+	addInflections(map[string]string{"Cactus": "Cacti", "person": "persons"})
+
+	if got := ToPlural("cactus"); got != "cacti" {
+		t.Errorf("ToPlural(cactus) = %q, want cacti", got)
+	}
+	if got := ToSingular("cacti"); got != "cactus" {
+		t.Errorf("ToSingular(cacti) = %q, want cactus", got)
+	}
+	if got := ToSingular("persons"); got != "person" {
+		t.Errorf("ToSingular(persons) = %q, want person", got)
+	}
+}
+
+var camelTests = []struct {
+	snake string
+	camel string
+}{
+	{"name", "Name"},
+	{"field_name", "FieldName"},
+	{"published_at", "PublishedAt"},
+	{"id", "ID"},
+	{"user_id", "UserID"},
+	{"html_body", "HTMLBody"},
+	{"api_url", "APIURL"},
+	{"utf8_name", "UTF8Name"},
+	{"address1_line", "Address1Line"},
+	{"ids", "IDs"},
+	{"user_ids", "UserIDs"},
+	{"urls", "URLs"},
+	{"api_urls", "APIURLs"},
+	{"urls_count", "URLsCount"},
+	{"a_b_c", "ABC"},
+	{"a_id", "AID"},
+	{"user_a", "UserA"},
+	{"v2", "V2"},
+	{"status", "Status"},
+}
+
+func TestToCamel(t *testing.T) {
+	for _, tt := range camelTests {
+		got := ToCamel(tt.snake)
+		if got != tt.camel {
+			t.Errorf("ToCamel(%q) = %q, want %q", tt.snake, got, tt.camel)
+		}
+	}
+}
+
+func TestToSnake(t *testing.T) {
+	for _, tt := range camelTests {
+		got := ToSnake(tt.camel)
+		if got != tt.snake {
+			t.Errorf("ToSnake(%q) = %q, want %q", tt.camel, got, tt.snake)
+		}
+	}
+
+	// Names not written by ToCamel
+	names := map[string]string{
+		"Id":             "id",
+		"PostId":         "post_id",
+		"APIKey":         "api_key",
+		"XMLHTTPRequest": "xml_http_request",
+		"ÉtéName":        "été_name",
+	}
+	for camel, snake := range names {
+		got := ToSnake(camel)
+		if got != snake {
+			t.Errorf("ToSnake(%q) = %q, want %q", camel, got, snake)
+		}
+	}
+}
+
+var truncateTests = []struct {
+	in     string
+	length int
+	want   string
+}{
+	{"abc", 5, "abc"},
+	{"héllo wörld", 5, "héll…"},
+	{"e\u0301té", 2, "e\u0301…"},
+	{"🇬🇧🇫🇷 flags", 4, "🇬🇧🇫🇷 …"},
+	{"👨\u200d👩\u200d👧 family", 3, "👨\u200d👩\u200d👧 …"},
+	{"日本語のテキスト", 4, "日本語…"},
+	{"日本語のテキスト", 1, "…"},
+}
+
+func TestTruncate(t *testing.T) {
+	for _, tt := range truncateTests {
+		got := Truncate(tt.in, tt.length)
+		if got != tt.want {
+			t.Errorf("Truncate(%q, %d) = %q, want %q", tt.in, tt.length, got, tt.want)
+		}
+	}
+}
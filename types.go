@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// fieldType describes how a type given to generate (e.g. title:text) is represented in go, sql, models and forms
+type fieldType struct {
+	GoType       string // e.g. time.Time
+	SQLType      string // e.g. timestamp
+	ValidateType string // the validate func used to read the column e.g. Time
+	InputType    string // the form input type e.g. date
+}
+
+// fieldTypes is the registry of types which may be used for columns in generate, keyed by type name
+// projects may add their own types in the types section of fragmenta.json, see registerConfigTypes
+var fieldTypes = map[string]fieldType{
+	"text":      {GoType: "string", SQLType: "text", ValidateType: "String", InputType: "textfield"},
+	"int":       {GoType: "int64", SQLType: "integer", ValidateType: "Int", InputType: "number"},
+	"bigint":    {GoType: "int64", SQLType: "bigint", ValidateType: "Int", InputType: "number"},
+	"float":     {GoType: "float64", SQLType: "real", ValidateType: "Float", InputType: "number"},
+	"double":    {GoType: "float64", SQLType: "double precision", ValidateType: "Float", InputType: "number"},
+	"decimal":   {GoType: "float64", SQLType: "numeric", ValidateType: "Float", InputType: "number"},
+	"money":     {GoType: "float64", SQLType: "numeric(12,2)", ValidateType: "Float", InputType: "number"},
+	"bool":      {GoType: "bool", SQLType: "boolean", ValidateType: "Boolean", InputType: "checkbox"},
+	"time":      {GoType: "time.Time", SQLType: "timestamp", ValidateType: "Time", InputType: "datetime-local"},
+	"date":      {GoType: "time.Time", SQLType: "date", ValidateType: "Time", InputType: "date"},
+	"json":      {GoType: "string", SQLType: "json", ValidateType: "String", InputType: "textarea"},
+	"jsonb":     {GoType: "string", SQLType: "jsonb", ValidateType: "String", InputType: "textarea"},
+	"uuid":      {GoType: "string", SQLType: "uuid", ValidateType: "String", InputType: "textfield"},
+	"text[]":    {GoType: "[]string", SQLType: "text[]", ValidateType: "String", InputType: "textfield"},
+	"char(255)": {GoType: "string", SQLType: "char(255)", ValidateType: "String", InputType: "textfield"},
+
+	// file and image columns hold the public path of a file uploaded with the form, images also have a thumbnail
+	"file":  {GoType: "string", SQLType: "text", ValidateType: "String", InputType: "file"},
+	"image": {GoType: "string", SQLType: "text", ValidateType: "String", InputType: "file"},
+
+	// enum is given with its values e.g. status:enum(draft,published,archived)
+	"enum": {GoType: "string", SQLType: "text", ValidateType: "String", InputType: "select"},
+}
+
+// fieldTypeAliases maps other names accepted by generate to a type in fieldTypes
+var fieldTypeAliases = map[string]string{
+	"string":    "text",
+	"integer":   "int",
+	"int64":     "int",
+	"float64":   "double",
+	"numeric":   "decimal",
+	"boolean":   "bool",
+	"timestamp": "time",
+	"datetime":  "time",
+}
+
+// lookupFieldType returns the registered type for name, following any alias
+func lookupFieldType(name string) (fieldType, bool) {
+	if len(fieldEnumValues(name)) > 0 {
+		name = "enum"
+	} else if name == "enum" {
+		// An enum must have values
+		return fieldType{}, false
+	}
+
+	if alias, ok := fieldTypeAliases[name]; ok {
+		name = alias
+	}
+	t, ok := fieldTypes[name]
+	return t, ok
+}
+
+// fieldTypeFor returns the registered type for name, or text if it is unknown
+// types are checked by checkFieldTypes before generating, so unknown types should not reach here
+func fieldTypeFor(name string) fieldType {
+	t, ok := lookupFieldType(name)
+	if !ok {
+		return fieldTypes["text"]
+	}
+	return t
+}
+
+// fieldEnumValues returns the values of an enum type like enum(draft,published), or nil if name is not an enum
+func fieldEnumValues(name string) []string {
+	if !strings.HasPrefix(name, "enum(") || !strings.HasSuffix(name, ")") {
+		return nil
+	}
+
+	var values []string
+	for _, v := range strings.Split(strings.TrimSuffix(strings.TrimPrefix(name, "enum("), ")"), ",") {
+		v = strings.TrimSpace(v)
+		if v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// enumValueRegexp matches the enum values which may be used, as each becomes part of the name of a go constant
+var enumValueRegexp = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// enumConstantName returns the name of the go constant for the value v of the enum column col e.g. StatusDraft
+func enumConstantName(col string, v string) string {
+	return ToCamel(col) + ToCamel(strings.Replace(v, "-", "_", -1))
+}
+
+// checkFieldTypes returns an error listing any columns with a type which is not registered,
+// or with enum values which cannot be used in the names of go constants
+func checkFieldTypes(cols map[string]string) error {
+	var unknown []string
+	for _, k := range sortedKeys(cols) {
+		if _, ok := lookupFieldType(cols[k]); !ok {
+			unknown = append(unknown, fmt.Sprintf("%s:%s", k, cols[k]))
+		}
+
+		names := map[string]string{}
+		for _, v := range fieldEnumValues(cols[k]) {
+			if !enumValueRegexp.MatchString(v) {
+				return fmt.Errorf("invalid value %q for enum %s, values must start with a letter and contain only a-z, 0-9, _ and -", v, k)
+			}
+			name := enumConstantName(k, v)
+			if names[name] != "" {
+				return fmt.Errorf("values %q and %q for enum %s both have the constant name %s", names[name], v, k, name)
+			}
+			names[name] = v
+		}
+	}
+
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown field types %s, use one of %s, enum(a,b,c) or add the type to the types section of fragmenta.json", strings.Join(unknown, ", "), strings.Join(sortedKeys(fieldTypeNames()), ", "))
+	}
+
+	return nil
+}
+
+// fieldTypeNames returns the registered type names and aliases, mapped to their type name
+func fieldTypeNames() map[string]string {
+	names := make(map[string]string, 0)
+	for k := range fieldTypes {
+		if k != "enum" {
+			names[k] = k
+		}
+	}
+	for k, v := range fieldTypeAliases {
+		names[k] = v
+	}
+	return names
+}
+
+// registerConfigTypes adds the types defined in the types section of fragmenta.json to fieldTypes
+// Each type is given as a list of key=value pairs separated by ; as sql types may contain commas,
+// any which are missing default to those of text:
+//
+//	"types": {"price": "go=float64;sql=numeric(10,2);validate=Float;input=number"}
+func registerConfigTypes(types map[string]string) error {
+	for name, spec := range types {
+		t := fieldTypes["text"]
+		for _, pair := range strings.Split(spec, ";") {
+			if strings.TrimSpace(pair) == "" {
+				continue
+			}
+			parts := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+			if len(parts) != 2 {
+				return fmt.Errorf("invalid type %s: %s", name, spec)
+			}
+			switch parts[0] {
+			case "go":
+				t.GoType = parts[1]
+			case "sql":
+				t.SQLType = parts[1]
+			case "validate":
+				t.ValidateType = parts[1]
+			case "input":
+				t.InputType = parts[1]
+			default:
+				return fmt.Errorf("invalid key %s in type %s", parts[0], name)
+			}
+		}
+		fieldTypes[strings.ToLower(name)] = t
+	}
+	return nil
+}
+
+// Convert a user-defined type to a validate func name
+func toValidateType(name string) string {
+	return fieldTypeFor(name).ValidateType
+}
+
+// Convert a user-defined type to a go type
+func toGoType(name string) string {
+	return fieldTypeFor(name).GoType
+}
+
+// Convert a user-defined type to an sql type
+// this may vary with the database
+func toSQLType(name string) string {
+	return fieldTypeFor(name).SQLType
+}
+
+// Convert a user-defined type to an input type
+func toInputType(name string) string {
+	return fieldTypeFor(name).InputType
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+var configTypeTests = []struct {
+	name  string
+	spec  string
+	want  fieldType
+	valid bool
+}{
+	{"point", "go=string;sql=point", fieldType{GoType: "string", SQLType: "point", ValidateType: "String", InputType: "textfield"}, true},
+	{"price", "go=float64; sql=numeric(10,2); validate=Float; input=number;", fieldType{GoType: "float64", SQLType: "numeric(10,2)", ValidateType: "Float", InputType: "number"}, true},
+	{"Code", "sql=char(3)", fieldType{GoType: "string", SQLType: "char(3)", ValidateType: "String", InputType: "textfield"}, true},
+	{"missing", "go", fieldType{}, false},
+	{"unknown", "colour=red", fieldType{}, false},
+}
+
+func TestRegisterConfigTypes(t *testing.T) {
+	for _, tt := range configTypeTests {
+		err := registerConfigTypes(map[string]string{tt.name: tt.spec})
+		if !tt.valid {
+			if err == nil {
+				t.Errorf("registerConfigTypes(%q) registered invalid spec %q", tt.name, tt.spec)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("registerConfigTypes(%q) error %s", tt.name, err)
+			continue
+		}
+
+		got, ok := lookupFieldType(strings.ToLower(tt.name))
+		if !ok || got != tt.want {
+			t.Errorf("registerConfigTypes(%q) registered %v, want %v", tt.name, got, tt.want)
+		}
+		delete(fieldTypes, strings.ToLower(tt.name))
+	}
+}
+
+var fieldTypeTests = []struct {
+	fieldType string
+	valid     bool
+}{
+	{"text", true},
+	{"string", true},
+	{"enum(draft,published)", true},
+	{"enum(in-review,v2,not_started)", true},
+	{"txt", false},
+	{"enum", false},
+	{"enum(v1.0)", false},
+	{"enum(two words)", false},
+	{"enum(2nd)", false},
+	{"enum(in-review,in_review)", false},
+}
+
+func TestCheckFieldTypes(t *testing.T) {
+	for _, tt := range fieldTypeTests {
+		err := checkFieldTypes(map[string]string{"col": tt.fieldType})
+		if tt.valid && err != nil {
+			t.Errorf("checkFieldTypes(%s) error %s", tt.fieldType, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("checkFieldTypes(%s) accepted an invalid type", tt.fieldType)
+		}
+	}
+}